@@ -1,4 +1,4 @@
-package main
+package wini
 
 type (
 	Pointer interface {
@@ -10,13 +10,22 @@ type (
 	Printer interface {
 		Print() string
 	}
+
+	//Range of nodes from h to t.
+	span struct {
+		h, t *lnode
+	}
 )
 
+func (s span) Range() (*lnode, *lnode) {
+	return s.h, s.t
+}
+
 //Call this to check how your File,Section,Keyvals look like as string.
 func Check(rg Ranger) string {
 	h, t := rg.Range()
 	str := ""
-	for {
+	for h != nil {
 		txt := h.text
 		emp := isEmpty(txt)
 		if !emp {
@@ -28,7 +37,7 @@ func Check(rg Ranger) string {
 		if !emp {
 			str += "\n"
 		}
-		if h.next != nil && (h.ntype != SEC && h.ntype != SECCOM) && (h.next.ntype == SEC || h.next.ntype == SECCOM) {
+		if h.next != nil && (!isSecType(h.ntype) && h.ntype != SECCOM && h.ntype != GLOBAL) && (isSecType(h.next.ntype) || h.next.ntype == SECCOM) {
 			str += "\n"
 		}
 		h = h.next
@@ -133,6 +142,9 @@ func isAdjacent(h1, t1, h2, t2 *lnode) bool {
 //head.prev,tail.prev of rg.Range() will be <nil>.
 func pop(rg Ranger) {
 	h, t := rg.Range()
+	if h == nil {
+		return
+	}
 	prev := h.prev
 	next := t.next
 	if prev != nil {
@@ -146,6 +158,11 @@ func pop(rg Ranger) {
 	t.next = nil
 }
 
+//Returns the head of the block `ptr` belongs to.
+//The block is the nodes sharing the identifier of `ptr` before it.
+//It never reaches into the previous keyval or section,
+//which can share the identifier when keys are repeated.
+//Leading empty lines belong to the previous block.
 func headBlock(ptr Pointer) *lnode {
 	node := ptr.Ptr()
 	for {
@@ -155,11 +172,19 @@ func headBlock(ptr Pointer) *lnode {
 		if node.identifier != node.prev.identifier {
 			break
 		}
+		if isBlockHead(node.prev.ntype) {
+			break
+		}
 		node = node.prev
 	}
+	for node != ptr.Ptr() && node.ntype == EMPTY {
+		node = node.next
+	}
 	return node
 }
 
+//Returns the tail of the block `ptr` belongs to,
+//which is `ptr` and the empty lines after it.
 func tailBlock(ptr Pointer) *lnode {
 	node := ptr.Ptr()
 	for {
@@ -169,7 +194,19 @@ func tailBlock(ptr Pointer) *lnode {
 		if node.identifier != node.next.identifier {
 			break
 		}
+		if node.next.ntype != EMPTY {
+			break
+		}
 		node = node.next
 	}
 	return node
 }
+
+//Reports whether nodes of `ntype` end blocks before them.
+func isBlockHead(ntype int) bool {
+	switch ntype {
+	case KEYVAL, SEC, ARRAY, GLOBAL, SECFOOT, FILEFOOT:
+		return true
+	}
+	return false
+}
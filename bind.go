@@ -0,0 +1,551 @@
+//Binding of File to Go structs.
+
+package wini
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	durationType        = reflect.TypeOf(time.Duration(0))
+
+	errBindTarget    = errors.New("wini: Unmarshal needs a non-nil pointer to struct")
+	errMarshalTarget = errors.New("wini: Marshal needs a struct or a non-nil pointer to struct")
+)
+
+//Parses `data` and stores it in the struct pointed by `v`.
+//See File.Unmarshal.
+func Unmarshal(data []byte, v any) error {
+	f, err := ParseBytes(data)
+	if err != nil {
+		return err
+	}
+	return f.Unmarshal(v)
+}
+
+//Stores key-val data of `file` in the struct pointed by `v`.
+//
+//Fields are matched with keys of the global section by their names,
+//or by `ini:"Name"` tags. Fields of struct type are matched with sections,
+//like `ini:"Author"`, and struct fields in them with sections named "Author.Child".
+//Fields of map[string]string type get Data of the section.
+//
+//Keys without value,like `skip-networking`,are true for bool fields.
+//
+//Options follow the name in the tag:
+//	Port int    `ini:"Port,required"` //error when missing.
+//	Host string `ini:"Host" default:"localhost"`
+//	Tags []string `ini:"Tag"` //repeated keys,comma-separated values,or lines of multi-line value.
+//Types implementing encoding.TextUnmarshaler are read by UnmarshalText.
+//Fields tagged `ini:"-"` are skipped. Errors are *ValueError.
+func (f *File) Unmarshal(v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return errBindTarget
+	}
+	return f.unmarshalStruct(f.Global(), "", rv.Elem())
+}
+
+//Creates a new File written in DefaultDialect from struct `v`.
+//See Dialect.Marshal.
+func Marshal(v any) (*File, error) {
+	return DefaultDialect.Marshal(v)
+}
+
+//Creates a new File written in Dialect `d` from struct `v`.
+//The result can be saved with Save or Savef.
+//
+//Fields are mapped the same way as File.Unmarshal. Fields in the top-level struct
+//come first as global keys, then sections follow in the order of the fields.
+//Sections of nested structs come after their parent section.
+//
+//A `comment:"..."` tag becomes comment of the section or the keyval.
+//The first comment symbol of `d` is put before the text when it lacks one.
+//	Port int `ini:"Port" comment:"Port to listen on."`
+//Slices are written as comma-separated values,or as repeated keys when a value contains ",".
+//Nil pointers and nil maps are not written.
+//Types implementing encoding.TextMarshaler are written by MarshalText.
+//Values that would not be read back as they are, like ones with new lines
+//or outer white spaces when `d` does not quote them, are errors wrapping ErrUnwritable.
+//Errors are *ValueError.
+func (d Dialect) Marshal(v any) (*File, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, errMarshalTarget
+	}
+	f := d.NewFile()
+	if err := f.marshalStruct(f.Global(), rv); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+//Field tag of struct binding.
+type fieldTag struct {
+	name     string
+	required bool
+	def      string
+	hasDef   bool
+	comments []string
+}
+
+func parseFieldTag(sf reflect.StructField) fieldTag {
+	tag := fieldTag{name: sf.Name}
+	opts := strings.Split(sf.Tag.Get("ini"), ",")
+	if len(opts[0]) > 0 {
+		tag.name = opts[0]
+	}
+	for _, opt := range opts[1:] {
+		if opt == "required" {
+			tag.required = true
+		}
+	}
+	tag.def, tag.hasDef = sf.Tag.Lookup("default")
+	if com := sf.Tag.Get("comment"); com != "" {
+		tag.comments = strings.Split(com, "\n")
+	}
+	return tag
+}
+
+//Reports whether fields of type `t` are matched with sections.
+func isSectionType(t reflect.Type) bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Map {
+		return t.Key().Kind() == reflect.String && t.Elem().Kind() == reflect.String
+	}
+	return t.Kind() == reflect.Struct && !reflect.PointerTo(t).Implements(textUnmarshalerType)
+}
+
+//Joins section names of nested structs.
+func subSectionName(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+//Stores `sec` in struct `rv`. `sec` is <nil> when the section is missing,
+//and only defaults are stored then.
+func (f *File) unmarshalStruct(sec *Section, name string, rv reflect.Value) error {
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		fv := rv.Field(i)
+		if sf.Anonymous && sf.Type.Kind() == reflect.Struct {
+			//embedded struct. its fields belong to `sec`.
+			if err := f.unmarshalStruct(sec, name, fv); err != nil {
+				return err
+			}
+			continue
+		}
+		tag := parseFieldTag(sf)
+		if !sf.IsExported() || tag.name == "-" {
+			continue
+		}
+		if isSectionType(sf.Type) {
+			if err := f.unmarshalSection(subSectionName(name, tag.name), tag, fv); err != nil {
+				return err
+			}
+			continue
+		}
+		var kvs KeyVals
+		if sec != nil {
+			kvs = sec.KeyAll(tag.name)
+		}
+		vals := fieldVals(kvs, sf.Type)
+		if len(vals) == 0 {
+			if tag.hasDef {
+				vals = []string{tag.def}
+			} else if tag.required {
+				return &ValueError{Section: name, Key: tag.name, Type: sf.Type.String(), Err: ErrNoKey}
+			} else {
+				continue
+			}
+		}
+		if err := setField(fv, vals); err != nil {
+			raw := strings.Join(vals, ",")
+			return &ValueError{Section: name, Key: tag.name, Val: raw, Type: sf.Type.String(), Err: err}
+		}
+	}
+	return nil
+}
+
+//Returns values of `kvs` to store in a field of type `t`.
+//Keys without value, like `skip-networking`, are true for bool fields.
+func fieldVals(kvs KeyVals, t reflect.Type) []string {
+	vals := make([]string, len(kvs))
+	for i, kv := range kvs {
+		vals[i] = kv.val
+		if kv.noVal && t.Kind() == reflect.Bool {
+			vals[i] = "true"
+		}
+	}
+	return vals
+}
+
+//Stores section `name` in `fv`, which is a struct or map[string]string field.
+func (f *File) unmarshalSection(name string, tag fieldTag, fv reflect.Value) error {
+	sec := f.Section(name)
+	if sec == nil && tag.required {
+		return &ValueError{Section: name, Type: fv.Type().String(), Err: ErrNoSection}
+	}
+	if fv.Kind() == reflect.Pointer {
+		if sec == nil {
+			return nil
+		}
+		if fv.IsNil() {
+			fv.Set(reflect.New(fv.Type().Elem()))
+		}
+		fv = fv.Elem()
+	}
+	if fv.Kind() == reflect.Map {
+		if sec == nil {
+			return nil
+		}
+		if fv.IsNil() {
+			fv.Set(reflect.MakeMap(fv.Type()))
+		}
+		for k, v := range sec.Data() {
+			fv.SetMapIndex(reflect.ValueOf(k).Convert(fv.Type().Key()), reflect.ValueOf(v).Convert(fv.Type().Elem()))
+		}
+		return nil
+	}
+	return f.unmarshalStruct(sec, name, fv)
+}
+
+//Stores `vals` in `fv`.
+//Slices get all of `vals`,or the comma-separated values when there is one.
+//Other types get the last one.
+func setField(fv reflect.Value, vals []string) error {
+	if fv.Kind() == reflect.Slice && !canUnmarshalText(fv) && fv.Type().Elem().Kind() != reflect.Uint8 {
+		if len(vals) == 1 {
+			vals = splitList(vals[0])
+		}
+		sl := reflect.MakeSlice(fv.Type(), len(vals), len(vals))
+		for i, v := range vals {
+			if err := setValue(sl.Index(i), v); err != nil {
+				return err
+			}
+		}
+		fv.Set(sl)
+		return nil
+	}
+	return setValue(fv, vals[len(vals)-1])
+}
+
+//Splits comma-separated values. Spaces around them are trimmed.
+//Multi-line values are split into lines instead, and empty lines are skipped.
+func splitList(s string) []string {
+	if strings.Contains(s, "\n") {
+		list := []string{}
+		for _, v := range strings.Split(s, "\n") {
+			if v = trimSpaces(v); v != "" {
+				list = append(list, v)
+			}
+		}
+		return list
+	}
+	if trimSpaces(s) == "" {
+		return []string{}
+	}
+	list := strings.Split(s, ",")
+	for i, v := range list {
+		list[i] = trimSpaces(v)
+	}
+	return list
+}
+
+func canUnmarshalText(fv reflect.Value) bool {
+	return fv.CanAddr() && fv.Addr().Type().Implements(textUnmarshalerType)
+}
+
+//Stores text `s` in `fv`.
+func setValue(fv reflect.Value, s string) error {
+	if canUnmarshalText(fv) {
+		return fv.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+	if fv.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		fv.SetInt(int64(d))
+		return nil
+	}
+	switch fv.Kind() {
+	case reflect.Pointer:
+		if fv.IsNil() {
+			fv.Set(reflect.New(fv.Type().Elem()))
+		}
+		return setValue(fv.Elem(), s)
+	case reflect.String:
+		fv.SetString(s)
+	case reflect.Bool:
+		b, err := parseBool(s)
+		if err != nil {
+			return err
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 0, fv.Type().Bits())
+		if err != nil {
+			return unwrapNumError(err)
+		}
+		fv.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u, err := strconv.ParseUint(s, 0, fv.Type().Bits())
+		if err != nil {
+			return unwrapNumError(err)
+		}
+		fv.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		fl, err := strconv.ParseFloat(s, fv.Type().Bits())
+		if err != nil {
+			return unwrapNumError(err)
+		}
+		fv.SetFloat(fl)
+	case reflect.Slice:
+		//[]byte
+		fv.SetBytes([]byte(s))
+	default:
+		return ErrUnsupportedType
+	}
+	return nil
+}
+
+func unwrapNumError(err error) error {
+	var ne *strconv.NumError
+	if errors.As(err, &ne) {
+		return ne.Err
+	}
+	return err
+}
+
+//Adds fields of struct `rv` to `sec`,and struct fields as sections to `f`.
+func (f *File) marshalStruct(sec *Section, rv reflect.Value) error {
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		fv := rv.Field(i)
+		if sf.Anonymous && sf.Type.Kind() == reflect.Struct {
+			if err := f.marshalStruct(sec, fv); err != nil {
+				return err
+			}
+			continue
+		}
+		tag := parseFieldTag(sf)
+		if !sf.IsExported() || tag.name == "-" {
+			continue
+		}
+		if isSectionType(sf.Type) {
+			if err := f.marshalSection(subSectionName(sec.name, tag.name), tag, fv); err != nil {
+				return err
+			}
+			continue
+		}
+		vals, err := formatField(fv)
+		if err != nil {
+			return &ValueError{Section: sec.name, Key: tag.name, Type: sf.Type.String(), Err: err}
+		}
+		if v, ok := sec.d.unwritable(vals); ok {
+			return &ValueError{Section: sec.name, Key: tag.name, Val: v, Type: sf.Type.String(), Err: ErrUnwritable}
+		}
+		if err := sec.marshalKey(tag, vals); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//Adds keyvals of `vals` to `sec`. Comments of `tag` go before the first one.
+func (sec *Section) marshalKey(tag fieldTag, vals []string) error {
+	coms, err := sec.d.comTexts(tag.comments)
+	if err != nil {
+		return err
+	}
+	for i, val := range vals {
+		kv := newKeyVal(sec.d, tag.name, val)
+		sec.AddKeyVal(kv)
+		if i == 0 {
+			if err := kv.AddCom(coms...); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+//Adds section `name` made from `fv`, which is a struct or map[string]string field.
+func (f *File) marshalSection(name string, tag fieldTag, fv reflect.Value) error {
+	if fv.Kind() == reflect.Pointer || fv.Kind() == reflect.Map {
+		if fv.IsNil() {
+			return nil
+		}
+	}
+	if fv.Kind() == reflect.Map {
+		if err := f.d.checkMap(name, fv); err != nil {
+			return err
+		}
+	}
+	coms, err := f.d.comTexts(tag.comments)
+	if err != nil {
+		return err
+	}
+	sec := newSection(f.d, name)
+	f.AddSec(sec)
+	if err := sec.AddCom(coms...); err != nil {
+		return err
+	}
+	if fv.Kind() == reflect.Pointer {
+		fv = fv.Elem()
+	}
+	if fv.Kind() == reflect.Map {
+		for _, k := range mapKeys(fv) {
+			v := fv.MapIndex(reflect.ValueOf(k).Convert(fv.Type().Key()))
+			sec.AddKeyVal(newKeyVal(f.d, k, v.String()))
+		}
+		return nil
+	}
+	return f.marshalStruct(sec, fv)
+}
+
+//Returns the first one of `vals` that would not be read back as it is, written in `d`.
+func (d *Dialect) unwritable(vals []string) (string, bool) {
+	for _, v := range vals {
+		if !d.readsBack(v) {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+//Returns a *ValueError when a value of map `fv`, written as section `name`,
+//would not be read back as it is.
+func (d *Dialect) checkMap(name string, fv reflect.Value) error {
+	for _, k := range mapKeys(fv) {
+		v := fv.MapIndex(reflect.ValueOf(k).Convert(fv.Type().Key())).String()
+		if _, ok := d.unwritable([]string{v}); ok {
+			return &ValueError{Section: name, Key: k, Val: v, Type: fv.Type().String(), Err: ErrUnwritable}
+		}
+	}
+	return nil
+}
+
+//Returns keys of map `fv` in sorted order.
+func mapKeys(fv reflect.Value) []string {
+	keys := make([]string, 0, fv.Len())
+	for _, k := range fv.MapKeys() {
+		keys = append(keys, k.String())
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+//Returns comment texts,with the first comment symbol of `d` put before those lacking one.
+//Returns an error wrapping ErrComSym when one lacks it and `d` has no comment symbol.
+func (d *Dialect) comTexts(texts []string) ([]string, error) {
+	coms := make([]string, len(texts))
+	for i, text := range texts {
+		if d.checkComSym(text) != nil {
+			if len(d.CommentSymbols) == 0 || d.CommentSymbols[0] == "" {
+				return nil, fmt.Errorf("%w:%v", ErrComSym, text)
+			}
+			text = d.CommentSymbols[0] + " " + text
+		}
+		coms[i] = text
+	}
+	return coms, nil
+}
+
+//Returns values of `fv` as texts. See Dialect.Marshal for slices.
+//Returns <nil> when `fv` is a nil pointer.
+func formatField(fv reflect.Value) ([]string, error) {
+	if isList(fv) {
+		vals, err := formatList(fv)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range vals {
+			if strings.Contains(s, ",") {
+				return vals, nil
+			}
+		}
+		return []string{strings.Join(vals, ", ")}, nil
+	}
+	if fv.Kind() == reflect.Pointer && fv.IsNil() {
+		return nil, nil
+	}
+	s, err := formatValue(fv)
+	if err != nil {
+		return nil, err
+	}
+	return []string{s}, nil
+}
+
+//Reports whether `fv` is a slice written as a list of values.
+func isList(fv reflect.Value) bool {
+	return fv.Kind() == reflect.Slice && !fv.Type().Implements(textMarshalerType) && fv.Type().Elem().Kind() != reflect.Uint8
+}
+
+//Returns elements of slice `fv` as texts.
+func formatList(fv reflect.Value) ([]string, error) {
+	vals := make([]string, fv.Len())
+	for i := range vals {
+		s, err := formatValue(fv.Index(i))
+		if err != nil {
+			return nil, err
+		}
+		vals[i] = s
+	}
+	return vals, nil
+}
+
+//Returns `fv` as text.
+func formatValue(fv reflect.Value) (string, error) {
+	if fv.Kind() == reflect.Pointer && fv.IsNil() {
+		return "", nil
+	}
+	if fv.Type().Implements(textMarshalerType) {
+		b, err := fv.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(b), err
+	}
+	if fv.CanAddr() && fv.Addr().Type().Implements(textMarshalerType) {
+		b, err := fv.Addr().Interface().(encoding.TextMarshaler).MarshalText()
+		return string(b), err
+	}
+	if fv.Type() == durationType {
+		return time.Duration(fv.Int()).String(), nil
+	}
+	switch fv.Kind() {
+	case reflect.Pointer:
+		return formatValue(fv.Elem())
+	case reflect.String:
+		return fv.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(fv.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(fv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(fv.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(fv.Float(), 'g', -1, fv.Type().Bits()), nil
+	case reflect.Slice:
+		//[]byte
+		return string(fv.Bytes()), nil
+	}
+	return "", ErrUnsupportedType
+}
@@ -0,0 +1,250 @@
+package wini
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestMarshalCommentWithoutSymbols(t *testing.T) {
+	type conf struct {
+		S struct {
+			Port int `ini:"port" comment:"Port to listen on."`
+		} `ini:"s" comment:"Server."`
+	}
+	d := DefaultDialect
+	d.CommentSymbols = nil
+	if _, err := d.Marshal(conf{}); !errors.Is(err, ErrComSym) {
+		t.Errorf("Marshal error = %v, want ErrComSym", err)
+	}
+
+	f := d.NewFile()
+	c := conf{}
+	c.S.Port = 80
+	if err := f.Update(&c, false); !errors.Is(err, ErrComSym) {
+		t.Errorf("Update error = %v, want ErrComSym", err)
+	}
+	if f.Section("s") != nil {
+		t.Error("section added before the error")
+	}
+}
+
+func TestMarshalComment(t *testing.T) {
+	type conf struct {
+		Port int `ini:"port" comment:"Port to listen on."`
+	}
+	f, err := Marshal(conf{Port: 80})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := f.Global().Key("port").Com(0).Get(); got != "# Port to listen on." {
+		t.Errorf("comment = %q", got)
+	}
+}
+
+type level int
+
+func (l *level) UnmarshalText(b []byte) error {
+	switch string(b) {
+	case "low":
+		*l = 1
+	case "high":
+		*l = 2
+	default:
+		return errors.New("unknown level")
+	}
+	return nil
+}
+
+func TestUnmarshal(t *testing.T) {
+	type server struct {
+		Host  string        `ini:"host" default:"localhost"`
+		Port  int           `ini:"port"`
+		Debug bool          `ini:"debug"`
+		Wait  time.Duration `ini:"wait"`
+		Level level         `ini:"level"`
+		Tags  []string      `ini:"tag"`
+		Hosts []string      `ini:"hosts"`
+		Child struct {
+			Name string `ini:"name"`
+		} `ini:"child"`
+	}
+	type conf struct {
+		Name   string            `ini:"name"`
+		Skip   string            `ini:"-"`
+		Server server            `ini:"server"`
+		Env    map[string]string `ini:"env"`
+		Opt    *server           `ini:"opt"`
+	}
+	src := "name = app\n-=x\n\n[server]\nport = 80\ndebug\nwait = 2s\nlevel = high\ntag = a\ntag = b\nhosts = x, y\n\n[server.child]\nname = c\n\n[env]\nHOME = /root\n"
+	var c conf
+	if err := Unmarshal([]byte(src), &c); err != nil {
+		t.Fatal(err)
+	}
+	s := c.Server
+	if c.Name != "app" || c.Skip != "" || s.Host != "localhost" || s.Port != 80 || !s.Debug || s.Wait != 2*time.Second || s.Level != 2 {
+		t.Errorf("Unmarshal = %+v", c)
+	}
+	if !equalStrings(s.Tags, []string{"a", "b"}) || !equalStrings(s.Hosts, []string{"x", "y"}) {
+		t.Errorf("lists = %q,%q", s.Tags, s.Hosts)
+	}
+	if s.Child.Name != "c" || c.Env["HOME"] != "/root" || c.Opt != nil {
+		t.Errorf("sections = %+v,%v,%v", s.Child, c.Env, c.Opt)
+	}
+}
+
+func TestUnmarshalMultiLineList(t *testing.T) {
+	var c struct {
+		Hosts []string `ini:"hosts"`
+	}
+	d := DefaultDialect
+	d.IndentContinuation = true
+	f, _ := d.ParseString("hosts =\n  a\n  b\n")
+	if err := f.Unmarshal(&c); err != nil {
+		t.Fatal(err)
+	}
+	if !equalStrings(c.Hosts, []string{"a", "b"}) {
+		t.Errorf("Hosts = %q", c.Hosts)
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	type required struct {
+		S struct {
+			Port int `ini:"port,required"`
+		} `ini:"s"`
+	}
+	var ve *ValueError
+	err := Unmarshal([]byte("[s]\n"), &required{})
+	if !errors.As(err, &ve) || ve.Section != "s" || ve.Key != "port" || !errors.Is(err, ErrNoKey) {
+		t.Errorf("required key error = %v", err)
+	}
+	var sec struct {
+		S struct{} `ini:"s,required"`
+	}
+	if err := Unmarshal([]byte(""), &sec); !errors.Is(err, ErrNoSection) {
+		t.Errorf("required section error = %v", err)
+	}
+	var bad struct {
+		Port int `ini:"port"`
+	}
+	err = Unmarshal([]byte("port = abc\n"), &bad)
+	if !errors.As(err, &ve) || ve.Val != "abc" || ve.Type != "int" || !errors.Is(err, strconv.ErrSyntax) {
+		t.Errorf("value error = %v", err)
+	}
+	if err := Unmarshal([]byte(""), bad); err == nil {
+		t.Error("Unmarshal to non-pointer succeeded")
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	type conf struct {
+		Name   string `ini:"name"`
+		Server struct {
+			Port  int           `ini:"port"`
+			Wait  time.Duration `ini:"wait"`
+			Hosts []string      `ini:"hosts"`
+			Notes []string      `ini:"note"`
+			Child struct {
+				On bool `ini:"on"`
+			} `ini:"child"`
+		} `ini:"server" comment:"Server."`
+		Env map[string]string `ini:"env"`
+		Nil *struct{}         `ini:"nil"`
+	}
+	c := conf{Name: "app", Env: map[string]string{"B": "2", "A": "1"}}
+	c.Server.Port = 80
+	c.Server.Wait = 2 * time.Second
+	c.Server.Hosts = []string{"x", "y"}
+	c.Server.Notes = []string{"a,b", "c"}
+	c.Server.Child.On = true
+	f, err := Marshal(&c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "name=app\n\n# Server.\n[server]\nport=80\nwait=2s\nhosts=x, y\nnote=a,b\nnote=c\n\n[server.child]\non=true\n\n[env]\nA=1\nB=2\n"
+	if got := f.text(); got != want {
+		t.Errorf("text() = %q, want %q", got, want)
+	}
+	var back conf
+	if err := f.Unmarshal(&back); err != nil {
+		t.Fatal(err)
+	}
+	if back.Name != c.Name || back.Server.Port != 80 || back.Server.Wait != c.Server.Wait || !back.Server.Child.On {
+		t.Errorf("Unmarshal(Marshal) = %+v", back)
+	}
+	if !equalStrings(back.Server.Hosts, c.Server.Hosts) || !equalStrings(back.Server.Notes, c.Server.Notes) {
+		t.Errorf("lists = %q,%q", back.Server.Hosts, back.Server.Notes)
+	}
+	if back.Env["A"] != "1" || back.Env["B"] != "2" {
+		t.Errorf("Env = %v", back.Env)
+	}
+}
+
+func TestMarshalUnwritable(t *testing.T) {
+	type conf struct {
+		A string `ini:"a"`
+		B string `ini:"b"`
+	}
+	quotes := DefaultDialect
+	quotes.Quotes = true
+	indent := DefaultDialect
+	indent.IndentContinuation = true
+	tests := []struct {
+		name string
+		d    Dialect
+		c    conf
+		bad  string //value reported. "" when it can be written.
+	}{
+		{"new line", DefaultDialect, conf{A: "x\n[evil]\ny=1"}, "x\n[evil]\ny=1"},
+		{"outer spaces", DefaultDialect, conf{B: "  pad"}, "  pad"},
+		{"quoted", quotes, conf{A: "x\n[evil]\ny=1", B: "  pad"}, ""},
+		{"indented", indent, conf{A: "x\ny"}, ""},
+		{"indented empty line", indent, conf{A: "x\n\ny"}, "x\n\ny"},
+		{"indented section", indent, conf{A: "x\n[evil]"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := tt.d.Marshal(tt.c)
+			if tt.bad != "" {
+				var ve *ValueError
+				if !errors.As(err, &ve) || ve.Val != tt.bad || !errors.Is(err, ErrUnwritable) {
+					t.Errorf("Marshal error = %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var back conf
+			g, _ := tt.d.ParseString(f.text())
+			if err := g.Unmarshal(&back); err != nil || back != tt.c || len(g.secs) != 0 {
+				t.Errorf("read back = %+v,%v from %q", back, err, f.text())
+			}
+		})
+	}
+}
+
+func TestUpdateUnwritable(t *testing.T) {
+	f, _ := ParseString("a = x\n")
+	c := struct {
+		A string            `ini:"a"`
+		M map[string]string `ini:"m"`
+	}{A: "x\ny"}
+	if err := f.Update(&c, false); !errors.Is(err, ErrUnwritable) {
+		t.Errorf("Update error = %v", err)
+	}
+	c.A = "x"
+	c.M = map[string]string{"k": " v"}
+	var ve *ValueError
+	if err := f.Update(&c, false); !errors.As(err, &ve) || ve.Section != "m" || ve.Key != "k" {
+		t.Errorf("Update error = %v", err)
+	}
+	if f.text() != "a = x\n" {
+		t.Errorf("text() = %q", f.text())
+	}
+	if _, err := Marshal(c); !errors.Is(err, ErrUnwritable) {
+		t.Errorf("Marshal error = %v", err)
+	}
+}
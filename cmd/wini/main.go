@@ -0,0 +1,26 @@
+// Command wini is a small demo of the wini package.
+// It loads the .ini file passed as the first argument,
+// and prints each section and its key-val data.
+//
+//	go run ./cmd/wini config.ini
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/zenryokukun/wini"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, "usage: wini <file.ini>")
+		os.Exit(2)
+	}
+	file, err := wini.Load(os.Args[1])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Println(wini.Check(file))
+}
@@ -1,11 +1,12 @@
 //Comment and block structs.
 
-package main
+package wini
 
 type (
 	Comment struct {
 		text string
 		ptr  *lnode
+		d    *Dialect
 	}
 
 	Comments []*Comment
@@ -14,22 +15,37 @@ type (
 	block struct {
 		comments Comments
 		ptr      *lnode
+		inline   string   //inline comment. Only used in inline comment mode.
+		d        *Dialect //Dialect the block is written in.
+	}
+
+	//Base struct for footer comments of Section and File.
+	//Footer comments come after the last key-val data.
+	footer struct {
+		foot Comments
 	}
 )
 
-//Creates *Comment from text.
-func NewComment(ntype int, id, text string) *Comment {
-	checkComSym(text)
+//Creates *Comment from text, written in DefaultDialect.
+//Returns an error wrapping ErrComSym when text does not start with a comment symbol.
+func NewComment(ntype int, id, text string) (*Comment, error) {
+	return newComment(defaultDialect(), ntype, id, text)
+}
+
+func newComment(d *Dialect, ntype int, id, text string) (*Comment, error) {
+	if err := d.checkComSym(text); err != nil {
+		return nil, err
+	}
 	l := &lnode{}
 	l.setType(ntype)
 	l.setIdentifier(id)
 	l.setText(text)
-	return &Comment{text: text, ptr: l}
+	return &Comment{text: text, ptr: l, d: d}, nil
 }
 
 //Creates Comment from lnode.Used when instantiating `file`.
-func newCommentFromNode(text string, ptr *lnode) *Comment {
-	return &Comment{text: text, ptr: ptr}
+func newCommentFromNode(d *Dialect, text string, ptr *lnode) *Comment {
+	return &Comment{text: text, ptr: ptr, d: d}
 }
 
 func (com *Comment) Ptr() *lnode {
@@ -61,7 +77,7 @@ func (coms Comments) Range() (*lnode, *lnode) {
 		if t.next == nil {
 			break
 		}
-		if t.next.ntype == SEC || t.next.ntype == KEYVAL {
+		if isSecType(t.next.ntype) || t.next.ntype == KEYVAL || t.next.ntype == GLOBAL {
 			break
 		}
 		t = t.next
@@ -74,12 +90,15 @@ func (c *Comment) Range() (*lnode, *lnode) {
 	return c.ptr.Range()
 }
 
-//Changes Comment text
-func (c *Comment) Change(text string) *Comment {
-	checkComSym(text)
+//Changes Comment text.
+//The comment is left as it is when text does not start with a comment symbol.
+func (c *Comment) Change(text string) error {
+	if err := c.d.checkComSym(text); err != nil {
+		return err
+	}
 	c.text = text
 	c.ptr.setText(text)
-	return c
+	return nil
 }
 
 //Pops section `comment` by index.
@@ -111,6 +130,12 @@ func (b *block) popComSlice(c *Comment) {
 	}
 }
 
+//Gets inline comment, with its comment symbol.
+//Returns "" when there is none.
+func (bl *block) InlineCom() string {
+	return bl.inline
+}
+
 //Gets comment.
 func (bl *block) Com(i int) *Comment {
 	if bl.comments == nil || (i > len(bl.comments)-1) || i < 0 {
@@ -119,13 +144,85 @@ func (bl *block) Com(i int) *Comment {
 	return bl.comments[i]
 }
 
+//Checks inline comment text. "" is fine, which removes inline comment.
+func (d *Dialect) checkInlineCom(text string) error {
+	if len(text) == 0 {
+		return nil
+	}
+	return d.checkComSym(text)
+}
+
 //Adds Comment(s).
 //`tnode`` is either section.ptr or kv.ptr.
 //All comments should be inserted before tnode.
-func (bl *block) addCom(tnode *lnode, ntype int, id string, texts ...string) {
+//Nothing is added when one of the texts lacks a comment symbol.
+func (bl *block) addCom(tnode *lnode, ntype int, id string, texts ...string) error {
+	coms := Comments{}
 	for _, text := range texts {
-		com := NewComment(ntype, id, text)
+		com, err := newComment(bl.d, ntype, id, text)
+		if err != nil {
+			return err
+		}
+		coms = append(coms, com)
+	}
+	for _, com := range coms {
 		tnode.insertBefore(com.ptr)
 		bl.comments = append(bl.comments, com)
 	}
+	return nil
+}
+
+//Gets footer comment.
+func (ft *footer) FootCom(i int) *Comment {
+	if i < 0 || i > len(ft.foot)-1 {
+		return nil
+	}
+	return ft.foot[i]
+}
+
+//Pops footer comment by index.
+func (ft *footer) PopFootCom(index int) {
+	if index < 0 || index > len(ft.foot)-1 {
+		return
+	}
+	pop(ft.foot[index])
+	ft.foot = append(ft.foot[:index], ft.foot[index+1:]...)
+}
+
+//Pops all footer comments.
+func (ft *footer) PopAllFootCom() {
+	for _, c := range ft.foot {
+		pop(c)
+	}
+	ft.foot = Comments{}
+}
+
+//Adds footer comment(s) after the existing ones.
+//`tnode` is the last node before footer comments, used when there is none yet.
+//Nothing is added when one of the texts lacks a comment symbol.
+func (ft *footer) addFootCom(d *Dialect, tnode *lnode, ntype int, id string, texts ...string) error {
+	coms := Comments{}
+	for _, text := range texts {
+		com, err := newComment(d, ntype, id, text)
+		if err != nil {
+			return err
+		}
+		coms = append(coms, com)
+	}
+	if len(ft.foot) > 0 {
+		tnode = ft.foot[len(ft.foot)-1].ptr
+	}
+	for _, com := range coms {
+		tnode.insert(com.ptr)
+		tnode = com.ptr
+		ft.foot = append(ft.foot, com)
+	}
+	return nil
+}
+
+//Sets Dialect to comments.
+func (coms Comments) setDialect(d *Dialect) {
+	for _, c := range coms {
+		c.d = d
+	}
 }
@@ -0,0 +1,147 @@
+package wini
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestFooterComments(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		secFoot []string //footer comments of the last section.
+		secName string
+		foot    []string //footer comments of the file.
+	}{
+		{"section footer before section", "[a]\nk=v\n# foot\n\n[b]\n", []string{"# foot"}, "a", nil},
+		{"file footer after empty line", "[a]\nk=v\n\n# end\n", nil, "a", []string{"# end"}},
+		{"section footer at the end", "[a]\nk=v\n# foot\n", []string{"# foot"}, "a", nil},
+		{"both at the end", "[a]\nk=v\n# foot\n\n# end\n", []string{"# foot"}, "a", []string{"# end"}},
+		{"only comments", "# a\n# b\n", nil, "", []string{"# a", "# b"}},
+		{"global footer", "k=v\n\n# end\n", nil, "", []string{"# end"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, _ := ParseString(tt.src)
+			if tt.secName != "" {
+				sec := f.Section(tt.secName)
+				if got := comTextsOf(sec.foot); !equalStrings(got, tt.secFoot) {
+					t.Errorf("section footer = %q, want %q", got, tt.secFoot)
+				}
+			}
+			if got := comTextsOf(f.foot); !equalStrings(got, tt.foot) {
+				t.Errorf("file footer = %q, want %q", got, tt.foot)
+			}
+		})
+	}
+}
+
+//Sections without keys have footer comments too.
+func TestFooterOfKeylessSection(t *testing.T) {
+	for _, src := range []string{"[a]\n# fa\n\n[b]\n", "[a]\n# fa\n"} {
+		f, _ := ParseString(src)
+		sec := f.Section("a")
+		if got := sec.FootCom(0); got == nil || got.Get() != "# fa" {
+			t.Errorf("%q: FootCom(0) = %v", src, got)
+			continue
+		}
+		sec.PopFootCom(0)
+		if got := f.text(); got != strings.Replace(src, "# fa\n", "", 1) {
+			t.Errorf("%q: text() after PopFootCom = %q", src, got)
+		}
+	}
+	f, _ := ParseString("[a]\n# fa\n# fb\n")
+	f.Section("a").PopAllFootCom()
+	if got := f.text(); got != "[a]\n" {
+		t.Errorf("text() after PopAllFootCom = %q", got)
+	}
+}
+
+func TestEditFooterComments(t *testing.T) {
+	f, _ := ParseString("[a]\nk=v\n\n[b]\nx=1\n")
+	if err := f.Section("a").AddFootCom("# foot of a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.AddFootCom("# end"); err != nil {
+		t.Fatal(err)
+	}
+	want := "[a]\nk=v\n# foot of a\n\n[b]\nx=1\n\n# end\n"
+	if got := f.text(); got != want {
+		t.Errorf("text() = %q, want %q", got, want)
+	}
+	if got := f.Section("a").FootCom(0).Get(); got != "# foot of a" {
+		t.Errorf("FootCom(0) = %q", got)
+	}
+	f.Section("a").PopFootCom(0)
+	f.PopFootCom(0)
+	if got := f.text(); got != "[a]\nk=v\n\n[b]\nx=1\n" {
+		t.Errorf("text() after PopFootCom = %q", got)
+	}
+}
+
+func comTextsOf(coms Comments) []string {
+	texts := []string{}
+	for _, c := range coms {
+		texts = append(texts, c.Get())
+	}
+	return texts
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func blockDialect() Dialect {
+	d := DefaultDialect
+	d.CommentSymbols = []string{"//", ";"}
+	d.BlockComment = [2]string{"/*", "*/"}
+	return d
+}
+
+func TestMultiCharComments(t *testing.T) {
+	src := "// banner\n\n/* about\n   section a */\n[a]\n// key\n/* one line */\nk=v\n"
+	f, err := blockDialect().ParseString(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sec := f.Section("a")
+	if got := comTextsOf(sec.comments); !equalStrings(got, []string{"/* about\n   section a */"}) {
+		t.Errorf("section comments = %q", got)
+	}
+	if got := comTextsOf(sec.Key("k").comments); !equalStrings(got, []string{"// key", "/* one line */"}) {
+		t.Errorf("key comments = %q", got)
+	}
+	if got := sec.Key("k").Val(); got != "v" {
+		t.Errorf("Val() = %q", got)
+	}
+	if f.text() != src {
+		t.Errorf("text() = %q", f.text())
+	}
+}
+
+func TestAddBlockComment(t *testing.T) {
+	f, _ := blockDialect().ParseString("[a]\nk=v\n")
+	if err := f.Section("a").AddCom("/* a\n b */", "// c"); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Section("a").Key("k").AddCom("/* open"); !errors.Is(err, ErrComSym) {
+		t.Errorf("AddCom of unclosed block comment error = %v", err)
+	}
+	want := "/* a\n b */\n// c\n[a]\nk=v\n"
+	if got := f.text(); got != want {
+		t.Errorf("text() = %q, want %q", got, want)
+	}
+	back, _ := blockDialect().ParseString(f.text())
+	if got := len(back.Section("a").comments); got != 2 {
+		t.Errorf("re-parsed comments = %v", got)
+	}
+}
@@ -0,0 +1,240 @@
+package wini
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+//Dialect is the set of symbols used to read and write ini text.
+//Pass it to the loaders such as Dialect.Load. The File keeps its Dialect,
+//and sections and key-val data added to the File are written in it.
+//Files with different Dialects can be read in parallel.
+//Empty SectionSymbols and Separator are read as "[","]" and "=",
+//and empty texts in CommentSymbols are skipped.
+type Dialect struct {
+	CommentSymbols []string  //texts starting with one of them are comments.
+	SectionSymbols [2]string //left and right symbols of section.
+	Separator      string    //key-val separator.
+	InlineComment  bool      //reads inline comments. See SetInlineCom.
+	//Reads quoted values like `name = "  padded  "`.
+	//Escapes such as \n,\t,\",\\ and \uXXXX are read in double quotes.
+	//Values are quoted when they need to be.
+	Quotes bool
+	//Lines ending with "\" continue on the next line, like systemd units.
+	//The lines are joined with a space.
+	LineContinuation bool
+	//Indented lines after a keyval continue its value, like Python configparser.
+	//The lines are joined with "\n".
+	IndentContinuation bool
+	//How sections sharing a name are treated. See DupPolicy.
+	Duplicates DupPolicy
+	//Open and close symbols of block comments, like {"/*", "*/"}.
+	//A line starting with the open symbol starts a comment,
+	//which goes on until a line with the close symbol.
+	//Leave both empty for no block comments.
+	BlockComment [2]string
+	//Reads headers with doubled section symbols like [[name]]
+	//as elements of array of tables, like TOML.
+	ArraySections bool
+	//Reads headers like [child : parent] of Zend config,
+	//where `child` inherits values of `parent`. See Section.Resolve.
+	//":" can not be used in section names then.
+	Inheritance bool
+	//Name of the section whose values apply to all sections,
+	//like "DEFAULT" of Python configparser. "" for none. See Section.Resolve.
+	DefaultSection string
+}
+
+//Presets of common dialects.
+var (
+	//`# comment`,`[section]`,`key=val`.
+	//Used by Load,Parse,NewFile,NewSection,and NewKeyVal.
+	DefaultDialect = Dialect{
+		CommentSymbols: []string{"#", ";"},
+		SectionSymbols: [2]string{"[", "]"},
+		Separator:      "=",
+	}
+	//Same as DefaultDialect, but `key:val`.
+	ColonDialect = Dialect{
+		CommentSymbols: []string{"#", ";"},
+		SectionSymbols: [2]string{"[", "]"},
+		Separator:      ":",
+	}
+	//php.ini. Only ";" starts comment,and inline comments are read.
+	PHPDialect = Dialect{
+		CommentSymbols: []string{";"},
+		SectionSymbols: [2]string{"[", "]"},
+		Separator:      "=",
+		InlineComment:  true,
+	}
+	//git config. Inline comments are read.
+	GitDialect = Dialect{
+		CommentSymbols: []string{"#", ";"},
+		SectionSymbols: [2]string{"[", "]"},
+		Separator:      "=",
+		InlineComment:  true,
+	}
+	//Python configparser. Values of [DEFAULT] apply to all sections,
+	//and indented lines continue values.
+	PythonDialect = Dialect{
+		CommentSymbols:     []string{"#", ";"},
+		SectionSymbols:     [2]string{"[", "]"},
+		Separator:          "=",
+		IndentContinuation: true,
+		DefaultSection:     "DEFAULT",
+	}
+)
+
+//Changes key-val separator of DefaultDialect.
+//
+//Deprecated: it is not safe to call while loading files in other goroutines.
+//Use a Dialect with its Separator set instead.
+func ChangeSepSym(ch string) {
+	DefaultDialect.Separator = ch
+}
+
+//Changes section symbols of DefaultDialect.
+//
+//Deprecated: it is not safe to call while loading files in other goroutines.
+//Use a Dialect with its SectionSymbols set instead.
+func ChangeSectionSym(left, right string) {
+	DefaultDialect.SectionSymbols = [2]string{left, right}
+}
+
+//Adds comment symbol to DefaultDialect.
+//
+//Deprecated: it is not safe to call while loading files in other goroutines.
+//Use a Dialect with its CommentSymbols set instead.
+func AddCommentSym(ch string) {
+	syms := append([]string{}, DefaultDialect.CommentSymbols...)
+	DefaultDialect.CommentSymbols = append(syms, ch)
+}
+
+//Turns inline comment mode of DefaultDialect on or off. It is off by default.
+//When on, a comment symbol after a space or a tab starts an inline comment,
+//like `Hobby = Fishing # inline comment`.
+//Put "\" before the symbol to use it in a value, like `Color = \#fff`.
+//
+//Deprecated: it is not safe to call while loading files in other goroutines.
+//Use a Dialect with InlineComment set instead.
+func SetInlineCom(on bool) {
+	DefaultDialect.InlineComment = on
+}
+
+//Same as Load, but reads the file in Dialect `d`.
+func (d Dialect) Load(fpath string) (*File, error) {
+	f, err := os.Open(fpath)
+	if err != nil {
+		return nil, &Error{Op: "load", Path: fpath, Err: err}
+	}
+	defer f.Close()
+	return parse(f, d.clone(), "load", fpath)
+}
+
+//Same as LoadFS, but reads the file in Dialect `d`.
+func (d Dialect) LoadFS(fsys fs.FS, name string) (*File, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, &Error{Op: "load", Path: name, Err: err}
+	}
+	defer f.Close()
+	return parse(f, d.clone(), "load", name)
+}
+
+//Same as Parse, but reads `r` in Dialect `d`.
+func (d Dialect) Parse(r io.Reader) (*File, error) {
+	return parse(r, d.clone(), "parse", "")
+}
+
+//Same as ParseString, but reads `s` in Dialect `d`.
+func (d Dialect) ParseString(s string) (*File, error) {
+	return d.Parse(strings.NewReader(s))
+}
+
+//Same as ParseBytes, but reads `b` in Dialect `d`.
+func (d Dialect) ParseBytes(b []byte) (*File, error) {
+	return d.Parse(bytes.NewReader(b))
+}
+
+//Returns empty File written in Dialect `d`.
+func (d Dialect) NewFile() *File {
+	f := &File{d: d.clone()}
+	f.relink()
+	return f
+}
+
+//Same as NewSection, but written in Dialect `d`.
+func (d Dialect) NewSection(text string) *Section {
+	return newSection(d.clone(), text)
+}
+
+//Same as NewKeyVal, but written in Dialect `d`.
+func (d Dialect) NewKeyVal(key, val string) *KeyVal {
+	return newKeyVal(d.clone(), key, val)
+}
+
+//Same as NewFlag, but written in Dialect `d`.
+func (d Dialect) NewFlag(key string) *KeyVal {
+	return newFlag(d.clone(), key)
+}
+
+//Returns a copy of `d`,which does not share CommentSymbols with `d`.
+//Empty symbols,which would match every line,are left out or set to those of "[key]" and "key=val".
+func (d Dialect) clone() *Dialect {
+	syms := []string{}
+	for _, sym := range d.CommentSymbols {
+		if sym != "" {
+			syms = append(syms, sym)
+		}
+	}
+	d.CommentSymbols = syms
+	if d.SectionSymbols[0] == "" {
+		d.SectionSymbols[0] = "["
+	}
+	if d.SectionSymbols[1] == "" {
+		d.SectionSymbols[1] = "]"
+	}
+	if d.Separator == "" {
+		d.Separator = "="
+	}
+	return &d
+}
+
+//Returns a copy of DefaultDialect.
+func defaultDialect() *Dialect {
+	return DefaultDialect.clone()
+}
+
+//Reports whether `d` and `o` read and write ini text the same way.
+func (d *Dialect) same(o *Dialect) bool {
+	if d == o {
+		return true
+	}
+	if d == nil || o == nil {
+		return false
+	}
+	if d.SectionSymbols != o.SectionSymbols || d.Separator != o.Separator || d.InlineComment != o.InlineComment {
+		return false
+	}
+	if d.Quotes != o.Quotes || d.LineContinuation != o.LineContinuation || d.IndentContinuation != o.IndentContinuation {
+		return false
+	}
+	if d.Duplicates != o.Duplicates || d.BlockComment != o.BlockComment || d.ArraySections != o.ArraySections {
+		return false
+	}
+	if d.Inheritance != o.Inheritance || d.DefaultSection != o.DefaultSection {
+		return false
+	}
+	if len(d.CommentSymbols) != len(o.CommentSymbols) {
+		return false
+	}
+	for i, sym := range d.CommentSymbols {
+		if sym != o.CommentSymbols[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,120 @@
+package wini
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestDialects(t *testing.T) {
+	tests := []struct {
+		name string
+		d    Dialect
+		src  string
+		sec  string
+		key  string
+		val  string
+	}{
+		{"default", DefaultDialect, "[a]\nk = v\n", "a", "k", "v"},
+		{"colon", ColonDialect, "[a]\nk: v\n", "a", "k", "v"},
+		{"php", PHPDialect, "[PHP]\nmemory_limit = 128M ; limit\n# not a comment = x\n", "PHP", "memory_limit", "128M"},
+		{"angle", Dialect{CommentSymbols: []string{"#"}, SectionSymbols: [2]string{"<", ">"}, Separator: "="}, "<a>\nk=v\n", "a", "k", "v"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := tt.d.ParseString(tt.src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sec := f.Section(tt.sec)
+			if sec == nil {
+				t.Fatalf("section %q not found", tt.sec)
+			}
+			if got := sec.Key(tt.key); got == nil || got.Val() != tt.val {
+				t.Errorf("Key(%q) = %v", tt.key, got)
+			}
+			if f.text() != tt.src {
+				t.Errorf("text() = %q", f.text())
+			}
+		})
+	}
+}
+
+//Files in different Dialects can be read at the same time.
+func TestDialectsConcurrent(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			f, _ := ColonDialect.ParseString("[a]\nk: v\n")
+			if f.Section("a").Key("k").Val() != "v" {
+				t.Error("colon dialect misread")
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			f, _ := DefaultDialect.ParseString("[a]\nk = v: w\n")
+			if f.Section("a").Key("k").Val() != "v: w" {
+				t.Error("default dialect misread")
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+//Sections and keyvals are rewritten in the Dialect of the File they are added to.
+func TestDialectOfAddedNodes(t *testing.T) {
+	d := Dialect{CommentSymbols: []string{";"}, SectionSymbols: [2]string{"<", ">"}, Separator: ":"}
+	f := d.NewFile()
+	sec := NewSection("a")
+	sec.AddKeyVal(NewKeyVal("k", "v"))
+	f.AddSec(sec)
+	if got := f.text(); got != "<a>\nk:v\n" {
+		t.Errorf("text() = %q", got)
+	}
+
+	dd := f.Dialect()
+	dd.CommentSymbols[0] = "#"
+	if f.Dialect().CommentSymbols[0] != ";" {
+		t.Error("Dialect() shares CommentSymbols with the File")
+	}
+}
+
+//Empty symbols do not match every line.
+func TestEmptySymbols(t *testing.T) {
+	tests := []struct {
+		name string
+		d    Dialect
+	}{
+		{"zero", Dialect{}},
+		{"separator only", Dialect{Separator: "="}},
+		{"empty comment symbol", Dialect{CommentSymbols: []string{"", "#"}}},
+		{"one section symbol", Dialect{SectionSymbols: [2]string{"[", ""}, CommentSymbols: []string{"#"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := "[a]\nk=v\n"
+			f, err := tt.d.ParseString(src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := secNamesOf(f.secs); got != "a" {
+				t.Fatalf("sections = %q", got)
+			}
+			if got := f.Section("a").Data(); len(got) != 1 || got["k"] != "v" {
+				t.Errorf("Data() = %v", got)
+			}
+			f.Section("a").Key("k").ChangeVal("w x")
+			if got := f.text(); got != "[a]\nk=w x\n" {
+				t.Errorf("text() = %q", got)
+			}
+		})
+	}
+	f, _ := Dialect{CommentSymbols: []string{"", "#"}}.ParseString("# c\n[a]\n")
+	if got := f.Section("a").Com(0); got == nil || got.Get() != "# c" {
+		t.Errorf("Com(0) = %v", got)
+	}
+	if f.Dialect().Separator != "=" {
+		t.Errorf("Dialect() = %+v", f.Dialect())
+	}
+}
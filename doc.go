@@ -0,0 +1,4 @@
+// Package wini is a .ini file parser.
+// It will let you read,edit,and create .ini file,
+// keeping comments and the order of sections and key-val data.
+package wini
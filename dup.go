@@ -0,0 +1,119 @@
+//Policies for duplicate sections.
+
+package wini
+
+//DupPolicy tells how sections sharing a name are treated.
+//Set it to Duplicates of Dialect.
+type DupPolicy int
+
+const (
+	//Sections sharing a name are separate Sections.
+	//Section,Pop,Swap and ChangeSectionName act on the first one.
+	//Use SectionAll to reach the others.
+	DupSeparate DupPolicy = iota
+	//Sections sharing a name are merged into one Section.
+	//Its key-val data are those of all of them, while their lines stay where they are.
+	//New key-val data are added to the first one.
+	//Pop and ChangeSectionName act on all of them,
+	//and Swap refuses them with an error wrapping ErrDupSection.
+	DupMerge
+	//Loading a file with sections sharing a name fails with an *Error
+	//wrapping ErrDupSection, holding the line of the second one.
+	//RenameSection and AddSection fail with an error wrapping ErrDupSection
+	//when the name is taken, and ChangeSectionName and AddSec do nothing.
+	DupError
+)
+
+//Returns the line number of `n`, counted from the head of the linked-list.
+func lineOf(n *lnode) int {
+	line := 1
+	for l := head(n); l != n; l = l.next {
+		if l.ntype == GLOBAL {
+			continue
+		}
+		line++
+		for i := 0; i < len(l.text); i++ {
+			if l.text[i] == '\n' {
+				line++ //multi-line value.
+			}
+		}
+	}
+	return line
+}
+
+//Returns the line of the first section whose name is taken by an earlier one.
+//Returns 0 when there is none.
+func (f *File) dupLine() int {
+	seen := map[string]bool{}
+	for _, sec := range f.secs {
+		if sec.IsArray() {
+			continue
+		}
+		if seen[sec.name] {
+			return lineOf(sec.ptr)
+		}
+		seen[sec.name] = true
+	}
+	return 0
+}
+
+//Merges sections sharing a name into the first one of them.
+func (f *File) foldDuplicates() {
+	secs := []*Section{}
+	for _, sec := range f.secs {
+		if first := sectionIn(secs, sec.name); first != nil && !sec.IsArray() {
+			first.fold(sec)
+		} else {
+			secs = append(secs, sec)
+		}
+	}
+	f.secs = secs
+}
+
+//Adds `dup` and its parts to the parts of `s`.
+//Key-val data of `dup` are now those of `s`.
+func (s *Section) fold(dup *Section) {
+	for _, kv := range dup.data {
+		kv.sec = s
+	}
+	s.data = append(s.data, dup.data...)
+	dup.data = KeyVals{}
+	parts := dup.parts
+	dup.parts = nil
+	s.parts = append(s.parts, dup)
+	s.parts = append(s.parts, parts...)
+}
+
+//Returns the first section named `name` in `secs`.
+func sectionIn(secs []*Section, name string) *Section {
+	for _, sec := range secs {
+		if sec.name == name && !sec.IsArray() {
+			return sec
+		}
+	}
+	return nil
+}
+
+//Returns elements of array of tables `name`, in the order they appear.
+//See ArraySections of Dialect.
+func (f *File) Array(name string) []*Section {
+	secs := []*Section{}
+	for _, sec := range f.secs {
+		if sec.name == name && sec.IsArray() {
+			secs = append(secs, sec)
+		}
+	}
+	return secs
+}
+
+//Returns all sections named `name`, in the order they appear.
+//There can be more than one on DupSeparate policy.
+func (f *File) SectionAll(name string) []*Section {
+	secs := []*Section{}
+	for _, sec := range f.secs {
+		if sec.name == name {
+			secs = append(secs, sec)
+		}
+	}
+	return secs
+}
@@ -0,0 +1,112 @@
+package wini
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+const dupSrc = "a=1\n\n[s]\nx=1\n; c\n[t]\ny=2\n\n[s]\nx=3\nz=4\n"
+
+func dupDialect(p DupPolicy) Dialect {
+	d := DefaultDialect
+	d.Duplicates = p
+	return d
+}
+
+func TestDupSeparate(t *testing.T) {
+	d := dupDialect(DupSeparate)
+	f, _ := d.ParseString(dupSrc)
+	if n := len(f.SectionAll("s")); n != 2 {
+		t.Fatalf("SectionAll = %d sections", n)
+	}
+	if got := f.Section("s").Data(); !reflect.DeepEqual(got, map[string]string{"x": "1"}) {
+		t.Errorf("Section(s).Data() = %v", got)
+	}
+	f.Pop("s")
+	if got := f.Section("s").Data(); !reflect.DeepEqual(got, map[string]string{"x": "3", "z": "4"}) {
+		t.Errorf("Data() after Pop = %v", got)
+	}
+}
+
+func TestDupMerge(t *testing.T) {
+	d := dupDialect(DupMerge)
+	f, _ := d.ParseString(dupSrc)
+	if n := len(f.Sections()); n != 2 {
+		t.Fatalf("Sections = %d", n)
+	}
+	if got := f.Section("s").Data(); !reflect.DeepEqual(got, map[string]string{"x": "3", "z": "4"}) {
+		t.Errorf("Data() = %v", got)
+	}
+	if err := f.Swap("s", "t"); !errors.Is(err, ErrDupSection) {
+		t.Errorf("Swap error = %v, want ErrDupSection", err)
+	}
+	f.ChangeSectionName("s", "u")
+	want := "a=1\n\n[u]\nx=1\n; c\n[t]\ny=2\n\n[u]\nx=3\nz=4\n"
+	if got := f.text(); got != want {
+		t.Errorf("text() after rename = %q", got)
+	}
+	f.Pop("u")
+	if got := f.text(); got != "a=1\n\n; c\n[t]\ny=2\n\n" {
+		t.Errorf("text() after Pop = %q", got)
+	}
+
+	f, _ = d.ParseString("[s]\nx=1\n[t]\ny=2\n")
+	if err := f.Swap("s", "t"); err != nil {
+		t.Errorf("Swap of sections without duplicates = %v", err)
+	}
+	f.AddSec(NewSection("t"))
+	if n := len(f.Sections()); n != 2 {
+		t.Errorf("AddSec of a taken name: %d sections", n)
+	}
+}
+
+func TestDupError(t *testing.T) {
+	d := dupDialect(DupError)
+	_, err := d.ParseString("[a]\nk=1\n[b]\n\n[a]\n")
+	var e *Error
+	if !errors.Is(err, ErrDupSection) || !errors.As(err, &e) || e.Line != 5 {
+		t.Fatalf("ParseString error = %v", err)
+	}
+
+	f, _ := d.ParseString("[a]\n[b]\n")
+	if err := f.RenameSection("a", "b"); !errors.Is(err, ErrDupSection) {
+		t.Errorf("RenameSection error = %v", err)
+	}
+	if err := f.RenameSection("x", "y"); !errors.Is(err, ErrNoSection) {
+		t.Errorf("RenameSection of missing section = %v", err)
+	}
+	if err := f.AddSection(NewSection("c"), NewSection("a")); !errors.Is(err, ErrDupSection) {
+		t.Errorf("AddSection error = %v", err)
+	}
+	if err := f.AddSection(NewSection("c"), NewSection("c")); !errors.Is(err, ErrDupSection) {
+		t.Errorf("AddSection of the same name twice = %v", err)
+	}
+	if f.text() != "[a]\n[b]\n" {
+		t.Errorf("text() after failed edits = %q", f.text())
+	}
+	if err := f.RenameSection("a", "c"); err != nil || f.Section("c") == nil {
+		t.Errorf("RenameSection = %v", err)
+	}
+}
+
+//A section named like the last key of the section before it is a section of its own.
+func TestSectionNamedLikeKey(t *testing.T) {
+	for _, src := range []string{"[x]\nk=1\n\n[k]\nk=2\n", "[x]\nk=1\n\n# about k\n[k]\nk=2\n"} {
+		f, _ := ParseString(src)
+		if got := secNamesOf(f.Sections()); got != "x,k" {
+			t.Errorf("%q: Sections() = %v", src, got)
+			continue
+		}
+		if got := f.Section("x").Values("k"); !equalStrings(got, []string{"1"}) {
+			t.Errorf("%q: x values = %q", src, got)
+		}
+		if got := f.Section("k").Key("k"); got == nil || got.Val() != "2" {
+			t.Errorf("%q: Key(k) of [k] = %v", src, got)
+		}
+		f.Pop("k")
+		if got := f.text(); got != "[x]\nk=1\n\n" {
+			t.Errorf("%q: text() after Pop = %q", src, got)
+		}
+	}
+}
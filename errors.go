@@ -0,0 +1,84 @@
+package wini
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrComSym is reported when a comment text does not start with a comment symbol.
+	ErrComSym = errors.New("lacking comment symbol")
+	// ErrSecSym is reported when a section name cannot be written between section symbols,
+	// so that it is read back as it is.
+	ErrSecSym = errors.New("invalid section name")
+	// ErrNoKey is reported when a key is not found in a section.
+	ErrNoKey = errors.New("key not found")
+	// ErrNoSection is reported when a section is not found in a file.
+	ErrNoSection = errors.New("section not found")
+	// ErrUnsupportedType is reported when a struct field cannot be bound to ini text.
+	ErrUnsupportedType = errors.New("unsupported type")
+	// ErrUnwritable is reported when a value would not be read back as it is,
+	// such as one with new lines or outer white spaces in a Dialect without quoting.
+	ErrUnwritable = errors.New("value cannot be written in the dialect")
+	// ErrDupSection is reported when a section name is repeated on DupError policy.
+	ErrDupSection = errors.New("duplicate section")
+	// ErrInheritCycle is reported when sections inherit from each other in a loop.
+	ErrInheritCycle = errors.New("inheritance cycle")
+)
+
+// Error is returned when reading or writing an ini file fails.
+// It records what was being done, the file path, and the line number
+// when the failure can be tied to a line.
+type Error struct {
+	Op   string // "load", "save" or "backup"
+	Path string // file path. Empty when not reading from a file.
+	Line int    // 1-based line number. 0 when unknown.
+	Err  error
+}
+
+func (e *Error) Error() string {
+	pos := e.Path
+	if e.Line > 0 && pos == "" {
+		pos = fmt.Sprintf("line %d", e.Line)
+	} else if e.Line > 0 {
+		pos = fmt.Sprintf("%v:%d", pos, e.Line)
+	}
+	if pos == "" {
+		return fmt.Sprintf("wini: %v: %v", e.Op, e.Err)
+	}
+	return fmt.Sprintf("wini: %v %v: %v", e.Op, pos, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// ValueError is returned when a value cannot be read as the requested type.
+// It records the section, the key and the raw text of the value.
+type ValueError struct {
+	Section string // section name. "" for the global section.
+	Key     string
+	Val     string // raw text of the value.
+	Type    string // "int", "float", "bool", "duration", "time", or Go type when binding structs.
+	Err     error
+}
+
+func (e *ValueError) Error() string {
+	if e.Key == "" {
+		return fmt.Sprintf("wini: [%v]: %v", e.Section, e.Err)
+	}
+	if errors.Is(e.Err, ErrNoKey) {
+		return fmt.Sprintf("wini: [%v] %v: %v", e.Section, e.Key, e.Err)
+	}
+	if errors.Is(e.Err, ErrUnwritable) {
+		return fmt.Sprintf("wini: [%v] %v: cannot write %q: %v", e.Section, e.Key, e.Val, e.Err)
+	}
+	if errors.Is(e.Err, ErrUnsupportedType) {
+		return fmt.Sprintf("wini: [%v] %v: %v %v", e.Section, e.Key, e.Err, e.Type)
+	}
+	return fmt.Sprintf("wini: [%v] %v: cannot read %q as %v: %v", e.Section, e.Key, e.Val, e.Type, e.Err)
+}
+
+func (e *ValueError) Unwrap() error {
+	return e.Err
+}
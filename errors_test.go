@@ -0,0 +1,111 @@
+package wini
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadError(t *testing.T) {
+	fpath := filepath.Join(t.TempDir(), "missing.ini")
+	_, err := Load(fpath)
+	var e *Error
+	if !errors.As(err, &e) {
+		t.Fatalf("Load error = %v, want *Error", err)
+	}
+	if e.Op != "load" || e.Path != fpath || !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Load error = %+v", e)
+	}
+}
+
+func TestSaveError(t *testing.T) {
+	fpath := filepath.Join(t.TempDir(), "no", "such", "dir.ini")
+	f, _ := ParseString("[a]\n")
+	err := f.Save(fpath)
+	var e *Error
+	if !errors.As(err, &e) || e.Op != "save" || e.Path != fpath {
+		t.Errorf("Save error = %v", err)
+	}
+}
+
+func TestSaveBackup(t *testing.T) {
+	dir := t.TempDir()
+	fpath := filepath.Join(dir, "a.ini")
+	os.WriteFile(fpath, []byte("[a]\nk=1\n"), 0644)
+	f, _ := Load(fpath)
+	f.Section("a").Key("k").ChangeVal("2")
+	if err := f.Save(fpath); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, "winiBK_a.ini"))
+	if err != nil || string(b) != "[a]\nk=1\n" {
+		t.Errorf("backup = %q,%v", b, err)
+	}
+}
+
+func TestComSymError(t *testing.T) {
+	f, _ := ParseString("[a]\nk=1\n")
+	if err := f.Section("a").AddCom("no symbol"); !errors.Is(err, ErrComSym) {
+		t.Errorf("AddCom error = %v", err)
+	}
+	if err := f.Section("a").Key("k").AddCom("# ok", "no symbol"); !errors.Is(err, ErrComSym) {
+		t.Errorf("AddCom error = %v", err)
+	}
+	if f.text() != "[a]\nk=1\n" {
+		t.Errorf("comments added before the error: %q", f.text())
+	}
+	if _, err := NewComment(SECCOM, "a", "x"); !errors.Is(err, ErrComSym) {
+		t.Errorf("NewComment error = %v", err)
+	}
+}
+
+func TestErrorMessage(t *testing.T) {
+	tests := []struct {
+		err  *Error
+		want string
+	}{
+		{&Error{Op: "load", Path: "a.ini", Err: fs.ErrNotExist}, "wini: load a.ini: file does not exist"},
+		{&Error{Op: "load", Path: "a.ini", Line: 3, Err: ErrDupSection}, "wini: load a.ini:3: duplicate section"},
+		{&Error{Op: "parse", Line: 3, Err: ErrDupSection}, "wini: parse line 3: duplicate section"},
+		{&Error{Op: "parse", Err: ErrDupSection}, "wini: parse: duplicate section"},
+	}
+	for _, tt := range tests {
+		if got := tt.err.Error(); got != tt.want {
+			t.Errorf("Error() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestSecSymError(t *testing.T) {
+	d := DefaultDialect
+	d.InlineComment = true
+	d.Inheritance = true
+	tests := []struct {
+		name string
+		ok   bool
+	}{
+		{"", false},
+		{"a\n[b]", false},
+		{"a ;c", false},
+		{"a : b", false},
+		{"a]", true},
+		{" a", true},
+	}
+	for _, tt := range tests {
+		f, _ := d.ParseString("[s]\n")
+		if err := f.AddSection(NewSection("x"), NewSection(tt.name)); errors.Is(err, ErrSecSym) == tt.ok {
+			t.Errorf("AddSection(%q) error = %v", tt.name, err)
+		}
+		if !tt.ok && f.Section("x") != nil {
+			t.Errorf("%q: sections = %q", tt.name, secNamesOf(f.secs))
+		}
+		if err := f.RenameSection("s", tt.name); errors.Is(err, ErrSecSym) == tt.ok {
+			t.Errorf("RenameSection(%q) error = %v", tt.name, err)
+		}
+		if tt.ok && f.Section(tt.name) == nil {
+			t.Errorf("%q: sections = %q", tt.name, secNamesOf(f.secs))
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package wini_test
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/zenryokukun/wini"
+)
+
+func ExampleParseString() {
+	file, err := wini.ParseString("# Name and age of the author.\n[Author]\nName = ZEN\nAge = 1\n")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	author := file.Section("Author")
+	fmt.Println(author.Data())
+	fmt.Println(author.Com(0).Get())
+	// Output:
+	// map[Age:1 Name:ZEN]
+	// # Name and age of the author.
+}
+
+func ExampleFile_WriteTo() {
+	file, _ := wini.ParseString("[Author]\nName = ZEN\n")
+	file.Section("Author").Key("Name").ChangeVal("zen")
+	file.Section("Author").AddKeyVal(wini.NewKeyVal("Age", "1"))
+	file.WriteTo(os.Stdout)
+	// Output:
+	// [Author]
+	// Name = zen
+	// Age = 1
+}
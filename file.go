@@ -1,170 +1,466 @@
-package main
+package wini
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
 	"io"
-	"log"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
 )
 
-type File map[string]*Section
+//File is the whole ini document.
+//It holds both ends of the linked-list,
+//and its sections in the order they appear in the document.
+//Key-val data and comments before the first section belong to
+//the nameless global section, which is not listed in `secs`.
+//Comments at the end of the file, apart from the last key-val data,
+//are footer comments of the file.
+type File struct {
+	footer
+	head       *lnode
+	tail       *lnode
+	global     *Section
+	secs       []*Section
+	d          *Dialect
+	bom        bool   //starts with UTF-8 byte order mark.
+	eol        string //line terminator of new lines. "\n" when empty.
+	noFinalEOL bool   //the last line has no line terminator.
+}
 
 //`fpath` is the config file path.
-//It reads up the file, and link each line as linked-list.
-//Returns the linked-list as a File map.
-func Load(fpath string) File {
-	f, err := os.Open(fpath)
-	if err != nil {
-		log.Fatal(err)
-	}
-	scanner := bufio.NewScanner(f)
-	scanner.Scan()
-	head := newLNode(scanner.Text())
-	tail := head
+//It reads up the file in DefaultDialect, and link each line as linked-list.
+//Returns the linked-list as a *File.
+//The returned error is an *Error holding the path, and the line number
+//when reading failed in the middle of the file.
+func Load(fpath string) (*File, error) {
+	return DefaultDialect.Load(fpath)
+}
+
+//Same as Load, but reads `name` from `fsys`.
+//Useful for defaults kept in embed.FS.
+func LoadFS(fsys fs.FS, name string) (*File, error) {
+	return DefaultDialect.LoadFS(fsys, name)
+}
+
+//Reads ini text from `r` until EOF, and returns it as a *File.
+func Parse(r io.Reader) (*File, error) {
+	return DefaultDialect.Parse(r)
+}
+
+//Parses ini text held in a string.
+func ParseString(s string) (*File, error) {
+	return DefaultDialect.ParseString(s)
+}
+
+//Parses ini text held in a byte slice.
+func ParseBytes(b []byte) (*File, error) {
+	return DefaultDialect.ParseBytes(b)
+}
+
+// internal. Called from Load,LoadFS and Parse.
+// Links each line of `r` as linked-list.
+// `op` and `fpath` are only used for error reporting.
+func parse(r io.Reader, d *Dialect, op, fpath string) (*File, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Split(scanLines)
+	var head, tail *lnode
+	line := 0
+	bom, eol := false, ""
+	inBlockCom := false
 
 	for scanner.Scan() {
-		line := scanner.Text()
-		node := newLNode(line)
-		tail.insert(node)
+		line++
+		text := scanner.Text()
+		if line == 1 && strings.HasPrefix(text, utf8BOM) {
+			bom = true
+			text = text[len(utf8BOM):]
+		}
+		text, lineEOL := splitEOL(text)
+		if eol == "" {
+			eol = lineEOL
+		}
+		if inBlockCom {
+			//block comment. One node holds all of its lines.
+			tail.setText(tail.text + tail.eol + text)
+			tail.eol = lineEOL
+			inBlockCom = !d.closesBlockCom(text)
+			continue
+		}
+		if tail != nil && d.continues(tail, text) {
+			//multi-line value. One node holds all of its lines.
+			tail.setText(tail.text + tail.eol + text)
+			tail.eol = lineEOL
+			continue
+		}
+		node := d.newLNode(text)
+		node.eol = lineEOL
+		if head == nil {
+			head = node
+		} else {
+			tail.insert(node)
+		}
 		tail = node
+		inBlockCom = d.opensBlockCom(text)
+	}
+	if err := scanner.Err(); err != nil {
+		//scanning stopped at the line after the last one read.
+		return nil, &Error{Op: op, Path: fpath, Line: line + 1, Err: err}
+	}
+	if head == nil {
+		//empty input.
+		f := &File{d: d, bom: bom}
+		f.relink()
+		return f, nil
 	}
 
+	global := newGlobal(d)
+	head = classifyGlobal(head, global.ptr)
 	classifyComments(head)
 	classifyEmptyLines(tail)
 
-	return newFile(head)
+	f := newFile(head, global)
+	f.bom, f.eol, f.noFinalEOL = bom, eol, tail.eol == ""
+	switch d.Duplicates {
+	case DupMerge:
+		f.foldDuplicates()
+	case DupError:
+		if l := f.dupLine(); l > 0 {
+			return nil, &Error{Op: op, Path: fpath, Line: l, Err: ErrDupSection}
+		}
+	}
+	return f, nil
+}
+
+const utf8BOM = "\uFEFF"
+
+//Split function of bufio.Scanner.
+//Same as bufio.ScanLines, but line terminators are left in the lines.
+func scanLines(data []byte, atEOF bool) (int, []byte, error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		return i + 1, data[:i+1], nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+//Splits `line` into its text and line terminator, which is "\r\n","\n" or "".
+func splitEOL(line string) (string, string) {
+	if strings.HasSuffix(line, "\r\n") {
+		return line[:len(line)-2], "\r\n"
+	}
+	if strings.HasSuffix(line, "\n") {
+		return line[:len(line)-1], "\n"
+	}
+	return line, ""
 }
 
-// internal. Called from Load.
-func newFile(l *lnode) File {
-	var file File = File{}
-	//scroll to head
-	h := head(l)
+// internal. Called from parse.
+// `global` should already be linked to `l`.
+func newFile(l *lnode, global *Section) *File {
+	file := &File{head: head(l), tail: tail(l), global: global, d: global.d}
+	h := _addSecInfo(global, file.head)
+	h = addKeyValInfo(global, h)
 	for h != nil {
-		if h.ntype == SEC || h.ntype == SECCOM {
+		if isSecType(h.ntype) || h.ntype == SECCOM {
 			h = addSecInfo(file, h)
 		} else {
-			h = h.next
+			h = addFootInfo(file, h)
 		}
 	}
 	return file
 }
 
-//Returns empty File. Used to generate ini file from scratch.
-func NewFile() File {
-	return File{}
+//Returns empty File written in DefaultDialect.
+//Used to generate ini file from scratch.
+func NewFile() *File {
+	return DefaultDialect.NewFile()
+}
+
+//Returns the Dialect `file` is read and written in.
+func (f *File) Dialect() Dialect {
+	f.relink()
+	return *f.d.clone()
+}
+
+//Returns the nameless global section.
+//It holds key-val data and comments that come before the first section.
+//Adding key-val data or comments to it will write them at the top of the file.
+func (f *File) Global() *Section {
+	f.relink()
+	return f.global
+}
+
+//Returns the first section named `name`.
+//Returns <nil> when not found.
+func (f *File) Section(name string) *Section {
+	for _, sec := range f.secs {
+		if sec.name == name {
+			return sec
+		}
+	}
+	return nil
+}
+
+//Returns all sections in the order they appear in the document.
+func (f *File) Sections() []*Section {
+	secs := make([]*Section, len(f.secs))
+	copy(secs, f.secs)
+	return secs
 }
 
-//Merges File maps after the caller.
+//Merges Files after the caller.
 //They will be merged in order they are passed.
-func (f File) Merge(fs ...File) {
-	_, prevTail := f.Range()
+//Key-val data and comments of their global sections are moved to
+//the global section of the caller, and their footer comments follow
+//the footer comments of the caller.
+//Merged Files should not be used afterwards,
+//because their nodes are now linked to the caller.
+func (f *File) Merge(fs ...*File) {
+	f.relink()
 	for _, nf := range fs {
-		for k, v := range nf {
-			f[k] = v
+		nf.relink()
+		f.mergeGlobal(nf.global)
+		//Unlinks footer comments of `nf`.
+		var fh, ft *lnode
+		if bt := nf.bodyTail(); bt.next != nil {
+			fh, ft = bt.next, nf.tail
+			pop(span{fh, ft})
 		}
-		h, _ := nf.Range()
-		if prevTail.ntype != EMPTY && h.ntype != EMPTY {
-			empty := &lnode{ntype: EMPTY, identifier: prevTail.identifier}
-			prevTail.insert(empty)
-			prevTail = empty
+		//What is left in the global section of `nf` is not needed any more.
+		gh, gt := nf.global.Range()
+		h := gt.next
+		pop(span{gh, gt})
+		if h != nil {
+			t := tail(h)
+			prevTail := f.bodyTail()
+			if h.ntype != EMPTY {
+				prevTail = f.spacedBodyTail()
+			}
+			prevTail.insertBlock(span{h, t})
+			for _, sec := range nf.secs {
+				sec.setDialect(f.d)
+				sec.file = f
+			}
+			f.secs = append(f.secs, nf.secs...)
+			if f.d.Duplicates == DupMerge {
+				f.foldDuplicates()
+			}
 		}
-		prevTail.insertBlock(nf)
-		_, prevTail = f.Range()
+		if fh != nil {
+			_, t := f.Range()
+			t.insertBlock(span{fh, ft})
+			nf.foot.setDialect(f.d)
+			f.foot = append(f.foot, nf.foot...)
+		}
+		f.relink()
 	}
 }
 
-func (f File) ChangeSectionName(name, newName string) File {
-	//checkSecSym(newName)
-	sec := f[name]
-	sec.changeName(newName)
-	//change map key.
-	f[newName] = sec
-	delete(f, name)
+//Moves comments and key-val data of `g` to the global section of `f`.
+func (f *File) mergeGlobal(g *Section) {
+	for _, com := range g.comments {
+		pop(com)
+		f.global.ptr.insertBefore(com.ptr)
+		com.d = f.d
+		f.global.comments = append(f.global.comments, com)
+	}
+	g.comments = Comments{}
+	for _, kv := range g.data {
+		pop(kv)
+		f.global.AddKeyVal(kv)
+	}
+	g.data = KeyVals{}
+}
+
+//Changes section name from `name` to `newName`.
+//Does nothing when `name` is not found, when `newName` can not be written as a section name,
+//like one with new lines, or on DupError policy when `newName` is taken.
+//Call RenameSection to get the error.
+//See DupPolicy for sections sharing `name` or `newName`.
+func (f *File) ChangeSectionName(name, newName string) *File {
+	f.RenameSection(name, newName)
 	return f
 }
 
+//Same as ChangeSectionName, but returns an error wrapping ErrNoSection when `name` is not found,
+//an error wrapping ErrSecSym when `newName` can not be written as a section name,
+//and an error wrapping ErrDupSection on DupError policy when `newName` is taken.
+func (f *File) RenameSection(name, newName string) error {
+	f.relink()
+	sec := f.Section(name)
+	if sec == nil {
+		return fmt.Errorf("%w:%v", ErrNoSection, name)
+	}
+	if name == newName {
+		return nil
+	}
+	if err := f.d.checkSecSym(newName, sec.IsArray()); err != nil {
+		return err
+	}
+	if f.d.Duplicates == DupError && !sec.IsArray() && sectionIn(f.secs, newName) != nil {
+		return fmt.Errorf("%w:%v", ErrDupSection, newName)
+	}
+	sec.changeName(newName)
+	if f.d.Duplicates == DupMerge {
+		f.foldDuplicates()
+	}
+	return nil
+}
+
 //Adds section to file.
-func (f File) AddSec(ns ...*Section) File {
-	//File has no keys.
-	if len(f) == 0 {
-		fsec := ns[0]
-		f[fsec.name] = ns[0]
-		for _, s := range ns[1:] {
-			insertBlock(f, s)
-			f[s.name] = s
-		}
-		return f
-	}
-	//Has some keys..
+//On DupMerge policy, a section whose name is taken is merged into the section of the name.
+//On DupError policy, nothing is added when one of the names is taken.
+//Nothing is added either when one of the names can not be written as a section name.
+//Call AddSection to get the error.
+func (f *File) AddSec(ns ...*Section) *File {
+	f.AddSection(ns...)
+	return f
+}
+
+//Same as AddSec, but returns an error wrapping ErrSecSym when one of the names
+//can not be written as a section name in the Dialect of `file`,
+//and an error wrapping ErrDupSection on DupError policy when one of the names is taken.
+//Nothing is added then.
+func (f *File) AddSection(ns ...*Section) error {
+	f.relink()
 	for _, s := range ns {
-		insertBlock(f, s)
-		f[s.name] = s
+		if err := f.d.checkSecSym(s.name, s.IsArray()); err != nil {
+			return err
+		}
 	}
-	return f
+	if f.d.Duplicates == DupError {
+		taken := map[string]bool{}
+		for _, sec := range f.secs {
+			if !sec.IsArray() {
+				taken[sec.name] = true
+			}
+		}
+		for _, s := range ns {
+			if s.IsArray() {
+				continue
+			}
+			if taken[s.name] {
+				return fmt.Errorf("%w:%v", ErrDupSection, s.name)
+			}
+			taken[s.name] = true
+		}
+	}
+	for _, s := range ns {
+		s.setDialect(f.d)
+		//sections are added before footer comments of the file.
+		f.spacedBodyTail().insertBlock(s)
+		s.file = f
+		f.secs = append(f.secs, s)
+	}
+	if f.d.Duplicates == DupMerge {
+		f.foldDuplicates()
+	}
+	f.relink()
+	return nil
 }
 
 //Swaps sections.`s1` and `s2` are section keys without "[" and "]".
-func (f File) Swap(s1, s2 string) error {
-	k1, ok := f[s1]
-	if !ok {
+//On DupMerge policy, returns an error wrapping ErrDupSection
+//when one of them is merged from sections sharing the name,
+//since its parts are apart from each other.
+//See DupPolicy for sections sharing a name.
+func (f *File) Swap(s1, s2 string) error {
+	i1 := f.index(s1)
+	if i1 < 0 {
 		return fmt.Errorf("section name not found:%v", s1)
 	}
-	k2, ok := f[s2]
-	if !ok {
+	i2 := f.index(s2)
+	if i2 < 0 {
 		return fmt.Errorf("section name not found:%v", s2)
 	}
-	swap(k1, k2)
+	for _, sec := range []*Section{f.secs[i1], f.secs[i2]} {
+		if len(sec.parts) > 0 {
+			return fmt.Errorf("%w:%v", ErrDupSection, sec.name)
+		}
+	}
+	swap(f.secs[i1], f.secs[i2])
+	f.secs[i1], f.secs[i2] = f.secs[i2], f.secs[i1]
+	f.relink()
 	return nil
 }
 
 //Pops `section` from `file`.
 //`name` is the section name to pop.
-func (f File) Pop(name string) {
-	sec, ok := f[name]
-	if ok {
-		pop(sec)
-		delete(f, name) //delete from map.
+//See DupPolicy for sections sharing a name.
+func (f *File) Pop(name string) {
+	i := f.index(name)
+	if i < 0 {
+		return
+	}
+	f.popAt(i)
+}
+
+//Pops the `i`th section.
+func (f *File) popAt(i int) {
+	sec := f.secs[i]
+	for _, p := range append([]*Section{sec}, sec.parts...) {
+		h, t := p.Range()
+		f.unlink(h, t)
 	}
+	sec.file = nil
+	f.secs = append(f.secs[:i], f.secs[i+1:]...)
 }
 
-//Pops all comments from `file`.
-func (f File) PopAllCom() {
+//Pops all comments from `file`, including footer comments.
+func (f *File) PopAllCom() {
 	//pop all comment nodes.
 	h, _ := f.Range()
 	for h != nil {
-		if h.ntype == KEYCOM || h.ntype == SECCOM {
+		if h.ntype == KEYCOM || h.ntype == SECCOM || h.ntype == SECFOOT || h.ntype == FILEFOOT {
 			//You need to set the popping node to another variable
 			//,and set h to h.next before popping,because `pop`` will set .next and .prev to <nil>.
 			//Otherwise, h = h.next will be <nil> all the time and breaks
 			//before searching 'till the end of the linked-list.
 			nodeToPop := h
 			h = h.next
-			pop(nodeToPop)
+			f.unlink(nodeToPop, nodeToPop)
 		} else {
 			h = h.next
 		}
 	}
 	//clear comments list
-	for _, sec := range f {
+	f.foot = Comments{}
+	for _, sec := range append([]*Section{f.global}, f.secs...) {
+		for _, p := range sec.parts {
+			p.comments = Comments{}
+			p.foot = Comments{}
+		}
 		sec.comments = Comments{}
+		sec.foot = Comments{}
 		for _, kv := range sec.data {
 			kv.comments = Comments{}
 		}
 	}
 }
 
+//Adds footer comment(s) at the end of `file`.
+//Each text must start with a comment symbol.
+func (f *File) AddFootCom(texts ...string) error {
+	_, t := f.Range()
+	return f.footer.addFootCom(f.d, t, FILEFOOT, "", texts...)
+}
+
 //Pops all empty lines from `file`.
-func (f File) PopEmptyLines() {
+func (f *File) PopEmptyLines() {
 	h, _ := f.Range()
 	for h != nil {
 		if h.ntype == EMPTY {
 			nodeToPop := h
 			h = h.next
-			pop(nodeToPop)
+			f.unlink(nodeToPop, nodeToPop)
 		} else {
 			h = h.next
 		}
@@ -173,7 +469,7 @@ func (f File) PopEmptyLines() {
 
 // Removes left indents.
 // " " and tabs are considered as indents.
-func (f File) RemoveIndent() {
+func (f *File) RemoveIndent() {
 	h, _ := f.Range()
 	for {
 		if h == nil {
@@ -184,24 +480,87 @@ func (f File) RemoveIndent() {
 		h.setText(txt)
 		h = h.next
 	}
+	for _, sec := range append([]*Section{f.global}, f.secs...) {
+		for _, kv := range sec.data {
+			kv.style.indent = ""
+		}
+	}
 }
 
 //Returns the head and the tail of `File`.
 //This will be the full linked-list.
-func (f File) Range() (*lnode, *lnode) {
-	var ptr *lnode
-	//Just iterate once, to get the ptr to search from.
-	/*
-		Todo:
-			Change the File to struct and hold `head` and `tail` field.
-	*/
-	for _, sec := range f {
-		ptr = sec.ptr
-		break
+func (f *File) Range() (*lnode, *lnode) {
+	f.relink()
+	return f.head, f.tail
+}
+
+//Index of the first section named `name` in f.secs.
+//Returns -1 when not found.
+func (f *File) index(name string) int {
+	for i, sec := range f.secs {
+		if sec.name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+//Pops nodes from `h` to `t`, keeping head and tail of `File` valid.
+func (f *File) unlink(h, t *lnode) {
+	if f.head == h {
+		f.head = t.next
+	}
+	if f.tail == t {
+		f.tail = h.prev
+	}
+	pop(span{h, t})
+}
+
+//Returns the last node before footer comments of `File`.
+func (f *File) bodyTail() *lnode {
+	h, t := f.Range()
+	for n := h; n != nil; n = n.next {
+		if n.ntype == FILEFOOT {
+			return n.prev
+		}
 	}
-	h := head(ptr)
-	t := tail(ptr)
-	return h, t
+	return t
+}
+
+//Same as bodyTail, but an empty line is inserted after it
+//when it is not one, to keep sections added after it apart.
+func (f *File) spacedBodyTail() *lnode {
+	t := f.bodyTail()
+	if t.ntype == EMPTY || t.ntype == GLOBAL {
+		return t
+	}
+	empty := &lnode{ntype: EMPTY, identifier: t.identifier}
+	t.insert(empty)
+	return empty
+}
+
+//Re-computes head and tail of `File`.
+//Section and KeyVal methods can pop or insert nodes at both ends
+//without knowing the File, so both ends are searched from
+//the head node of the global section, which is never popped.
+//It also sets up the global section of a zero File,
+//and points sections to `File`, which may have been copied.
+func (f *File) relink() {
+	if f.d == nil {
+		f.d = defaultDialect()
+	}
+	if f.global == nil {
+		f.global = newGlobal(f.d)
+	}
+	f.global.file = f
+	for _, sec := range f.secs {
+		sec.file = f
+		for _, p := range sec.parts {
+			p.file = f
+		}
+	}
+	ptr := f.global.ptr
+	f.head, f.tail = head(ptr), tail(ptr)
 }
 
 //**********
@@ -209,19 +568,8 @@ func (f File) Range() (*lnode, *lnode) {
 // It will scroll to the head of the linked-list that Pointer belongs to,
 // and writes out the whole text.
 // New bkupfile will be created,when there is none.
-func (f File) Save(fpath string) {
-	head, _ := f.Range()
-	str := asString(head)
-	dir := filepath.Dir(fpath)
-	fname := filepath.Base(fpath)
-	bkpath := filepath.Join(dir, "winiBK_"+fname)
-	_, err := os.Stat(fpath)
-	_, errBK := os.Stat(bkpath)
-	if !os.IsNotExist(err) && os.IsNotExist(errBK) {
-		//create backup file,when fpath and bkup file do not exist.
-		backupFile(fpath, bkpath)
-	}
-	save(str, fpath)
+func (f *File) Save(fpath string) error {
+	return saveWithBackup(f.text(), fpath)
 }
 
 // Saves ini file.
@@ -233,7 +581,29 @@ func (f File) Save(fpath string) {
 //             number of empty lines before sections.
 // kvLines  -> number of empty Lines between keyvals.
 // indent   -> number of indentation of keyvals.
-func (f File) Savef(fpath string, secLines, kvLines, indent int) {
+func (f *File) Savef(fpath string, secLines, kvLines, indent int) error {
+	str := f.formatted(secLines, kvLines, indent)
+	return saveWithBackup(str, fpath)
+}
+
+// Writes the whole text of `file` to `w`, the same way Save does.
+// It implements io.WriterTo.
+func (f *File) WriteTo(w io.Writer) (int64, error) {
+	n, err := io.WriteString(w, f.text())
+	return int64(n), err
+}
+
+// internal. Called from Save and Encoder.
+// Returns the whole text of `file`.
+// Lines are written as they were read, unless they were edited.
+func (f *File) text() string {
+	head, _ := f.Range()
+	return f.prefix() + asString(head, f.lineEnd(), !f.noFinalEOL)
+}
+
+// internal. Called from Savef and Encoder.
+// Formats `file` and returns the whole text. See Savef for the parameters.
+func (f *File) formatted(secLines, kvLines, indent int) string {
 	// Removes all empty lines and indents.
 	// Call this before calling Range(),because
 	// the head could be an empty line.
@@ -241,69 +611,118 @@ func (f File) Savef(fpath string, secLines, kvLines, indent int) {
 	f.RemoveIndent()
 	head, _ := f.Range()
 	str := asStringf(head, secLines, kvLines, indent)
+	//multi-line values and block comments hold their own line terminators.
+	str = strings.ReplaceAll(str, "\r\n", "\n")
+	if f.noFinalEOL {
+		str = strings.TrimSuffix(str, "\n")
+	}
+	return f.prefix() + strings.ReplaceAll(str, "\n", f.lineEnd())
+}
+
+//Returns the byte order mark `file` was read with.
+func (f *File) prefix() string {
+	if f.bom {
+		return utf8BOM
+	}
+	return ""
+}
+
+//Returns the line terminator of new lines.
+//It is the first one `file` was read with, or "\n".
+func (f *File) lineEnd() string {
+	if f.eol == "" {
+		return "\n"
+	}
+	return f.eol
+}
+
+// internal. Called from Save and Savef.
+// Creates backup file when fpath exists and there is no backup file yet.
+func saveWithBackup(text, fpath string) error {
 	dir := filepath.Dir(fpath)
 	fname := filepath.Base(fpath)
 	bkpath := filepath.Join(dir, "winiBK_"+fname)
-
 	_, err := os.Stat(fpath)
 	_, errBK := os.Stat(bkpath)
 	if !os.IsNotExist(err) && os.IsNotExist(errBK) {
 		//create backup file,when fpath and bkup file do not exist.
-		backupFile(fpath, bkpath)
+		if err := backupFile(fpath, bkpath); err != nil {
+			return err
+		}
 	}
-	save(str, fpath)
+	return save(text, fpath)
 }
 
 // internal. Called from Save.
-func save(text, fpath string) {
+func save(text, fpath string) error {
 	f, err := os.Create(fpath)
 	if err != nil {
-		log.Fatal(err)
+		return &Error{Op: "save", Path: fpath, Err: err}
 	}
-	defer f.Close()
-	_, err = f.Write([]byte(text))
-	if err != nil {
-		fmt.Println(err)
+	if _, err = f.Write([]byte(text)); err != nil {
+		f.Close()
+		return &Error{Op: "save", Path: fpath, Err: err}
+	}
+	if err = f.Close(); err != nil {
+		return &Error{Op: "save", Path: fpath, Err: err}
 	}
+	return nil
 }
 
 //internal. Called from Save.
-func backupFile(fpath, bkpath string) {
+func backupFile(fpath, bkpath string) error {
 	f, err := os.Open(fpath)
 	if err != nil {
-		fmt.Println(fpath)
-		log.Fatal(err)
+		return &Error{Op: "backup", Path: fpath, Err: err}
 	}
 	defer f.Close()
 
 	bf, err := os.Create(bkpath)
 	if err != nil {
-		fmt.Println(bf)
-		log.Fatal(err)
+		return &Error{Op: "backup", Path: bkpath, Err: err}
 	}
-	defer bf.Close()
-	io.Copy(bf, f)
+	if _, err = io.Copy(bf, f); err != nil {
+		bf.Close()
+		return &Error{Op: "backup", Path: bkpath, Err: err}
+	}
+	if err = bf.Close(); err != nil {
+		return &Error{Op: "backup", Path: bkpath, Err: err}
+	}
+	return nil
 }
 
 //internal. Called from Save()
-func asString(n *lnode) string {
+func asString(n *lnode, eol string, final bool) string {
 	str := ""
-	for {
+	for n != nil {
+		if n.ntype == GLOBAL {
+			n = n.next
+			continue
+		}
 		str += n.text
-		if n.next == nil {
+		nxt := nextLine(n)
+		if nxt == nil {
+			if final {
+				str += lineEnd(n, eol)
+			}
 			break
 		}
-		str += "\n"
-
-		//Adding extra empty-line before [section] or its commets,
-		//if there is none.
-		if n.next.ntype == SEC {
-			if n.ntype != SECCOM && n.ntype != EMPTY {
-				str += "\n"
+		str += lineEnd(n, eol)
+
+		//Keeping comments of the global section and footer comments
+		//apart from what follows, so they are read as they are again.
+		//Lines read from a file already are.
+		if n.ntype == SECCOM && n.next.ntype == GLOBAL {
+			if nxt.ntype != EMPTY {
+				str += eol
 			}
-		} else if n.next.ntype == SECCOM {
-			if n.ntype != SECCOM && n.ntype != EMPTY {
-				str += "\n"
+		} else if nxt.ntype == FILEFOOT {
+			if n.ntype != FILEFOOT && n.ntype != EMPTY {
+				str += eol
+			}
+		} else if isSecType(nxt.ntype) || nxt.ntype == SECCOM {
+			if n.ntype == SECFOOT {
+				str += eol
 			}
 		}
 
@@ -312,25 +731,67 @@ func asString(n *lnode) string {
 	return str
 }
 
+//Returns the line terminator `n` was read with, or `eol` for new lines.
+func lineEnd(n *lnode, eol string) string {
+	if n.eol != "" {
+		return n.eol
+	}
+	return eol
+}
+
+//Returns the next node that is written out.
+func nextLine(n *lnode) *lnode {
+	n = n.next
+	for n != nil && n.ntype == GLOBAL {
+		n = n.next
+	}
+	return n
+}
+
 // internal. Called from Savef
 func asStringf(n *lnode, secLines, kvLines, indent int) string {
 	str := ""
+	inSec := false //keyvals of the global section are not indented.
 	for n != nil {
+		if n.ntype == GLOBAL {
+			n = n.next
+			continue
+		}
+		if isSecType(n.ntype) {
+			inSec = true
+		}
 		txt := n.text
-		if n.ntype == KEYVAL || n.ntype == KEYCOM {
+		if inSec && (n.ntype == KEYVAL || n.ntype == KEYCOM) {
 			txt = getStr(" ", indent) + txt
 		}
-		if n.ntype == SEC || n.ntype == KEYVAL {
+		if isSecType(n.ntype) || n.ntype == KEYVAL {
 			// new lines after section or keyval
 			txt += getStr("\n", kvLines)
 		}
 
-		if n.next != nil && (n.ntype != SEC && n.ntype != SECCOM) && (n.next.ntype == SECCOM || n.next.ntype == SEC) {
+		if n.next != nil && (!isSecType(n.ntype) && n.ntype != SECCOM) && (n.next.ntype == SECCOM || isSecType(n.next.ntype)) {
 			// new lines before section
 
 			txt += getStr("\n", secLines)
 		}
 
+		if n.ntype == SECFOOT && secLines == 0 && n.next != nil && (n.next.ntype == SECCOM || isSecType(n.next.ntype)) {
+			// keeps footer comments of section apart from the next section.
+			txt += "\n"
+		}
+
+		nxt := nextLine(n)
+		if (n.ntype == SECCOM && n.next != nil && n.next.ntype == GLOBAL && nxt != nil) ||
+			(n.ntype != FILEFOOT && nxt != nil && nxt.ntype == FILEFOOT) {
+			// keeps comments of the global section and footer comments of the file
+			// apart from the rest.
+			if secLines > 0 {
+				txt += getStr("\n", secLines)
+			} else {
+				txt += "\n"
+			}
+		}
+
 		str += txt + "\n"
 		n = n.next
 	}
@@ -351,13 +812,13 @@ func asStringf(n *lnode, secLines, kvLines, indent int) string {
 				break
 			}
 
-			if n.ntype == SEC || n.ntype == KEYVAL {
+			if isSecType(n.ntype) || n.ntype == KEYVAL {
 				// new lines after section or keyval
 				str += t + getStr("\n", kvLines)
 				hit = true
 			}
 
-			if (n.ntype != SEC && n.ntype != SECCOM) && (n.next.ntype == SECCOM || n.next.ntype == SEC) {
+			if (!isSecType(n.ntype) && n.ntype != SECCOM) && (n.next.ntype == SECCOM || isSecType(n.next.ntype)) {
 				// new lines before section
 				if hit == false {
 					str += t + getStr("\n", secLines)
@@ -378,19 +839,21 @@ func asStringf(n *lnode, secLines, kvLines, indent int) string {
 }
 
 //Called from newFile.
-func addSecInfo(f File, l *lnode) *lnode {
+func addSecInfo(f *File, l *lnode) *lnode {
 	for l != nil {
 		sec := &Section{}
+		sec.d = f.d
 
-		if l.ntype == SECCOM || l.ntype == SEC {
+		if l.ntype == SECCOM || isSecType(l.ntype) {
 			l = _addSecInfo(sec, l)
 			l = addKeyValInfo(sec, l)
 		} else {
-			l = l.next
+			l = addFootInfo(f, l)
 		}
 
-		if len(sec.name) > 0 {
-			f[sec.name] = sec
+		if sec.ptr != nil {
+			sec.file = f
+			f.secs = append(f.secs, sec)
 		}
 	}
 	return l
@@ -399,12 +862,26 @@ func addSecInfo(f File, l *lnode) *lnode {
 //Adds section name and ptr.
 func _addSecInfo(s *Section, l *lnode) *lnode {
 	id := l.identifier
-	for l.identifier == id {
+	seenHeader := false
+	for l != nil && l.identifier == id && l.ntype != FILEFOOT {
+		if seenHeader && (l.ntype == SECCOM || isSecType(l.ntype)) {
+			//same name repeated. It is another section.
+			break
+		}
+		if seenHeader && (l.ntype == KEYVAL || l.ntype == KEYCOM || l.ntype == EMPTY || l.ntype == SECFOOT) {
+			//keys and footer comments are read by addKeyValInfo.
+			break
+		}
 		if l.ntype == SECCOM {
-			s.comments = append(s.comments, newCommentFromNode(l.text, l))
-		} else if l.ntype == SEC {
+			s.comments = append(s.comments, newCommentFromNode(s.d, l.text, l))
+		} else if isSecType(l.ntype) || l.ntype == GLOBAL {
+			seenHeader = true
 			s.name = l.identifier
 			s.ptr = l
+			if l.ntype != GLOBAL {
+				s.bases = s.d.getBases(l.text)
+			}
+			_, s.inline = s.d.splitInlineCom(l.text)
 		}
 		l = l.next
 	}
@@ -415,11 +892,14 @@ func _addSecInfo(s *Section, l *lnode) *lnode {
 func addKeyValInfo(s *Section, l *lnode) *lnode {
 	//var kvs KeyVals = KeyVals{}
 	for l != nil {
-		if l.ntype == SEC || l.ntype == SECCOM {
+		if isSecType(l.ntype) || l.ntype == SECCOM || l.ntype == FILEFOOT {
 			break
 		}
 		if l.ntype == KEYCOM || l.ntype == KEYVAL {
 			l = _addKeyValInfo(s, l)
+		} else if l.ntype == SECFOOT {
+			s.foot = append(s.foot, newCommentFromNode(s.d, l.text, l))
+			l = l.next
 		} else {
 			l = l.next
 		}
@@ -428,17 +908,39 @@ func addKeyValInfo(s *Section, l *lnode) *lnode {
 	return l
 }
 
+//Adds footer comment of the file.
+func addFootInfo(f *File, l *lnode) *lnode {
+	if l.ntype == FILEFOOT {
+		f.foot = append(f.foot, newCommentFromNode(f.d, l.text, l))
+	}
+	return l.next
+}
+
 func _addKeyValInfo(s *Section, l *lnode) *lnode {
 	id := l.identifier
-	var kv *keyval = &keyval{}
-	for l != nil && l.identifier == id {
+	var kv *KeyVal = &KeyVal{}
+	kv.d = s.d
+	for l != nil && l.identifier == id && l.ntype != SECFOOT {
 		// if l == nil {
 		// 	break
 		// }
+		if kv.ptr != nil && (l.ntype == KEYCOM || l.ntype == KEYVAL) {
+			//same key repeated. It is another keyval.
+			break
+		}
+		if isSecType(l.ntype) || l.ntype == SECCOM || l.ntype == FILEFOOT {
+			//section named like the key. It is read by addSecInfo.
+			break
+		}
 		if l.ntype == KEYCOM {
-			kv.comments = append(kv.comments, newCommentFromNode(l.text, l))
+			kv.comments = append(kv.comments, newCommentFromNode(s.d, l.text, l))
 		} else if l.ntype == KEYVAL {
-			kv.key, kv.val = sepSplit(l.text)
+			text, com := s.d.splitInlineCom(l.text)
+			kv.key, kv.raw = s.d.sepSplit(text)
+			kv.noVal = !strings.Contains(text, s.d.Separator)
+			kv.val = s.d.readVal(s.d.joinLines(kv.raw))
+			kv.inline = com
+			kv.style = s.d.readStyle(l.text)
 			kv.ptr = l
 		}
 		l = l.next
@@ -0,0 +1,228 @@
+package wini
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"testing/iotest"
+)
+
+func TestBannerThenGlobalKeys(t *testing.T) {
+	f, err := ParseString("# top\n\nport = 8080\n[s]\nx=1\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := f.Global().Data(); !reflect.DeepEqual(got, map[string]string{"port": "8080"}) {
+		t.Errorf("Global().Data() = %v", got)
+	}
+	if got := f.Global().Com(0); got == nil || got.Get() != "# top" {
+		t.Errorf("Global().Com(0) = %v", got)
+	}
+	if secs := f.Sections(); len(secs) != 1 || secs[0].Name() != "s" {
+		t.Errorf("Sections() = %v", secs)
+	}
+
+	var c struct {
+		Port int `ini:"port"`
+	}
+	if err := f.Unmarshal(&c); err != nil || c.Port != 8080 {
+		t.Errorf("Unmarshal = %v,%v", c.Port, err)
+	}
+}
+
+func TestRepeatedHeaders(t *testing.T) {
+	f, _ := ParseString("[s]\n[s]\nx=1\n")
+	secs := f.SectionAll("s")
+	if len(secs) != 2 {
+		t.Fatalf("SectionAll = %d sections", len(secs))
+	}
+	if len(secs[0].Data()) != 0 || secs[1].Data()["x"] != "1" {
+		t.Errorf("Data() = %v,%v", secs[0].Data(), secs[1].Data())
+	}
+}
+
+//Keys named like their section belong to the section.
+func TestKeyNamedLikeSection(t *testing.T) {
+	f, _ := ParseString("[database]\ndatabase = mydb\nhost = x\n\n[a]\n# about\na=1\n")
+	if got := f.Section("database").Data(); !reflect.DeepEqual(got, map[string]string{"database": "mydb", "host": "x"}) {
+		t.Errorf("Data() = %v", got)
+	}
+	kv := f.Section("a").Key("a")
+	if kv == nil || kv.Val() != "1" || kv.Com(0) == nil || kv.Com(0).Get() != "# about" {
+		t.Errorf("Key(a) = %v", kv)
+	}
+	if len(f.Section("a").comments) != 0 {
+		t.Errorf("section comments = %q", comTextsOf(f.Section("a").comments))
+	}
+
+	var c struct {
+		DB struct {
+			Name string `ini:"database"`
+		} `ini:"database"`
+	}
+	if err := f.Unmarshal(&c); err != nil || c.DB.Name != "mydb" {
+		t.Errorf("Unmarshal = %q,%v", c.DB.Name, err)
+	}
+	f.Section("database").Key("database").ChangeVal("other")
+	if got := f.text(); got != "[database]\ndatabase = other\nhost = x\n\n[a]\n# about\na=1\n" {
+		t.Errorf("text() = %q", got)
+	}
+}
+
+func TestZeroFile(t *testing.T) {
+	var f File
+	f.AddSec(NewSection("a"))
+	f.ChangeSectionName("a", "b")
+	if f.Section("b") == nil {
+		t.Fatal("section not added to zero File")
+	}
+	var g File
+	g.ChangeSectionName("a", "b")
+	g.Global().AddKeyVal(NewKeyVal("k", "v"))
+	if g.text() != "k=v\n" {
+		t.Errorf("text() = %q", g.text())
+	}
+}
+
+func TestParseSources(t *testing.T) {
+	const src = "[a]\nk=v\n"
+	fsys := fstest.MapFS{"conf/a.ini": {Data: []byte(src)}}
+	load := map[string]func() (*File, error){
+		"Parse":       func() (*File, error) { return Parse(strings.NewReader(src)) },
+		"ParseString": func() (*File, error) { return ParseString(src) },
+		"ParseBytes":  func() (*File, error) { return ParseBytes([]byte(src)) },
+		"LoadFS":      func() (*File, error) { return LoadFS(fsys, "conf/a.ini") },
+	}
+	for name, fn := range load {
+		f, err := fn()
+		if err != nil {
+			t.Errorf("%v: %v", name, err)
+			continue
+		}
+		var buf bytes.Buffer
+		n, err := f.WriteTo(&buf)
+		if err != nil || buf.String() != src || n != int64(len(src)) {
+			t.Errorf("%v: WriteTo = %q,%v,%v", name, buf.String(), n, err)
+		}
+	}
+	_, err := LoadFS(fsys, "missing.ini")
+	var e *Error
+	if !errors.As(err, &e) || e.Path != "missing.ini" || !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("LoadFS error = %v", err)
+	}
+}
+
+//Parse reports a read error with the line it failed at.
+func TestParseReadError(t *testing.T) {
+	r := io.MultiReader(strings.NewReader("[a]\nk=v\n"), iotest.ErrReader(errors.New("broken")))
+	_, err := Parse(r)
+	var e *Error
+	if !errors.As(err, &e) || e.Line != 3 {
+		t.Errorf("Parse error = %v", err)
+	}
+}
+
+func TestSectionOrder(t *testing.T) {
+	f, _ := ParseString("[c]\n[a]\n[b]\n")
+	if got := secNamesOf(f.Sections()); got != "c,a,b" {
+		t.Errorf("Sections() = %v", got)
+	}
+	if err := f.Swap("c", "b"); err != nil {
+		t.Fatal(err)
+	}
+	if got := secNamesOf(f.Sections()); got != "b,a,c" {
+		t.Errorf("Sections() after Swap = %v", got)
+	}
+	if got := f.text(); got != "[b]\n[a]\n[c]\n" {
+		t.Errorf("text() after Swap = %q", got)
+	}
+	if err := f.Swap("b", "x"); err == nil {
+		t.Error("Swap with a missing section succeeded")
+	}
+}
+
+//Range keeps returning both ends of the list when its ends are popped or added to.
+func TestRangeEnds(t *testing.T) {
+	f, _ := ParseString("[a]\nk=v\n[b]\nx=1\n")
+	f.Pop("a")
+	h, tl := f.Range()
+	if h.prev != nil || tl.next != nil || tl.text != "x=1" {
+		t.Errorf("Range() after Pop = %q..%q", h.text, tl.text)
+	}
+	f.Pop("b")
+	if got := f.text(); got != "" {
+		t.Errorf("text() after popping all = %q", got)
+	}
+	f.AddSec(NewSection("c"))
+	f.AddFootCom("# end")
+	if _, tl = f.Range(); tl.text != "# end" {
+		t.Errorf("tail = %q", tl.text)
+	}
+	if got := f.text(); got != "[c]\n\n# end\n" {
+		t.Errorf("text() = %q", got)
+	}
+}
+
+func secNamesOf(secs []*Section) string {
+	names := []string{}
+	for _, s := range secs {
+		names = append(names, s.Name())
+	}
+	return strings.Join(names, ",")
+}
+
+func TestGlobalSection(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		data     map[string]string
+		secComs  int //comments of the global section.
+		keyComs  int //comments of the first global key.
+		sections int
+	}{
+		{"no global", "[a]\nk=v\n", map[string]string{}, 0, 0, 1},
+		{"keys only", "a=1\nb=2\n", map[string]string{"a": "1", "b": "2"}, 0, 0, 0},
+		{"keys before section", "a=1\n[s]\nx=1\n", map[string]string{"a": "1"}, 0, 0, 1},
+		{"banner", "# license\n\na=1\n[s]\n", map[string]string{"a": "1"}, 1, 0, 1},
+		{"key comment", "# about a\na=1\n", map[string]string{"a": "1"}, 0, 1, 0},
+		{"banner before section", "# license\n\n[s]\nx=1\n", map[string]string{}, 1, 0, 1},
+		{"section comment", "# about s\n[s]\nx=1\n", map[string]string{}, 0, 0, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, _ := ParseString(tt.src)
+			g := f.Global()
+			if got := g.Data(); !reflect.DeepEqual(got, tt.data) {
+				t.Errorf("Data() = %v", got)
+			}
+			if n := len(g.comments); n != tt.secComs {
+				t.Errorf("%d comments, want %d", n, tt.secComs)
+			}
+			if len(g.data) > 0 && len(g.data[0].comments) != tt.keyComs {
+				t.Errorf("%d key comments, want %d", len(g.data[0].comments), tt.keyComs)
+			}
+			if n := len(f.Sections()); n != tt.sections {
+				t.Errorf("%d sections, want %d", n, tt.sections)
+			}
+		})
+	}
+}
+
+func TestGlobalAddKeyVal(t *testing.T) {
+	f, _ := ParseString("# license\n\n[s]\nx=1\n")
+	f.Global().AddKeyVal(NewKeyVal("port", "80"))
+	want := "# license\n\nport=80\n\n[s]\nx=1\n"
+	if got := f.text(); got != want {
+		t.Errorf("text() = %q, want %q", got, want)
+	}
+	//the banner stays a banner when read back.
+	f, _ = ParseString(want)
+	if g := f.Global(); len(g.comments) != 1 || g.Data()["port"] != "80" || len(g.data[0].comments) != 0 {
+		t.Errorf("read back: %v comments, data %v", len(g.comments), g.Data())
+	}
+}
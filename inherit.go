@@ -0,0 +1,140 @@
+//Section inheritance and the defaults section.
+
+package wini
+
+import (
+	"fmt"
+	"strings"
+)
+
+//Splits section header body `body` like `child : parent` into the name and the names it inherits from.
+//`body` is returned as it is when inheritance mode is off.
+func (d *Dialect) splitInherit(body string) (string, []string) {
+	if !d.Inheritance || !strings.Contains(body, ":") {
+		return body, nil
+	}
+	parts := strings.Split(body, ":")
+	bases := []string{}
+	for _, b := range parts[1:] {
+		if b = trimSpaces(b); b != "" {
+			bases = append(bases, d.unescapeSecSym(b))
+		}
+	}
+	return trimSpaces(parts[0]), bases
+}
+
+//Returns the header line of section `name` inheriting from `bases`.
+func (d *Dialect) secHeader(name string, bases []string, array bool) string {
+	if len(bases) == 0 {
+		return d.secText(name, array)
+	}
+	left, right := d.secSyms(array)
+	text := left + d.escapeSecSym(name)
+	for _, b := range bases {
+		text += " : " + d.escapeSecSym(b)
+	}
+	return text + right
+}
+
+//Returns names of the sections `section` inherits from, like "parent" of [child : parent].
+//See Inheritance of Dialect.
+func (s *Section) Inherits() []string {
+	return append([]string{}, s.bases...)
+}
+
+//Returns the keyval of `key` that applies to `section`.
+//`key` is looked up in `section`, then in the sections it inherits from, in the order they are written,
+//and then in the defaults section. See Inheritance and DefaultSection of Dialect.
+//The last one is returned when `key` is repeated, as in Data.
+//Call Section of the keyval to see where the value came from.
+//
+//Returns an error wrapping ErrNoKey when not found,
+//ErrNoSection when an inherited section is missing,
+//and ErrInheritCycle when sections inherit from each other in a loop.
+func (s *Section) Resolve(key string) (*KeyVal, error) {
+	chain, err := s.chain()
+	if err != nil {
+		return nil, err
+	}
+	for _, sec := range chain {
+		if kvs := sec.KeyAll(key); len(kvs) > 0 {
+			return kvs[len(kvs)-1], nil
+		}
+	}
+	return nil, fmt.Errorf("%w:%v", ErrNoKey, key)
+}
+
+//Same as Data, but with key-val data `section` inherits,
+//from the sections it inherits from and the defaults section.
+//Values are the ones Resolve returns. See Resolve for errors.
+func (s *Section) EffectiveData() (map[string]string, error) {
+	chain, err := s.chain()
+	if err != nil {
+		return nil, err
+	}
+	m := map[string]string{}
+	for i := len(chain) - 1; i >= 0; i-- {
+		for k, v := range chain[i].Data() {
+			m[k] = v
+		}
+	}
+	return m, nil
+}
+
+//Returns sections that `key` is looked up in, in order.
+//Each section is listed once, even when inherited through several paths.
+func (s *Section) chain() ([]*Section, error) {
+	chain := []*Section{}
+	seen := map[*Section]bool{}
+	var walk func(sec *Section, path []*Section) error
+	walk = func(sec *Section, path []*Section) error {
+		path = append(path, sec)
+		for _, p := range path[:len(path)-1] {
+			if p == sec {
+				return fmt.Errorf("%w:%v", ErrInheritCycle, secNames(path))
+			}
+		}
+		if seen[sec] {
+			return nil
+		}
+		seen[sec] = true
+		chain = append(chain, sec)
+		for _, name := range sec.bases {
+			base := sec.lookupSection(name)
+			if base == nil {
+				return fmt.Errorf("%w:%v", ErrNoSection, name)
+			}
+			if err := walk(base, path); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(s, nil); err != nil {
+		return nil, err
+	}
+	if s.d.DefaultSection == "" {
+		return chain, nil
+	}
+	if def := s.lookupSection(s.d.DefaultSection); def != nil && !seen[def] {
+		chain = append(chain, def)
+	}
+	return chain, nil
+}
+
+//Returns section `name` of the File `section` is in. Returns <nil> when not found.
+func (s *Section) lookupSection(name string) *Section {
+	if s.file == nil {
+		return nil
+	}
+	return s.file.Section(name)
+}
+
+//Returns names of `secs` joined by " -> ".
+func secNames(secs []*Section) string {
+	names := make([]string, len(secs))
+	for i, sec := range secs {
+		names[i] = sec.name
+	}
+	return strings.Join(names, " -> ")
+}
@@ -0,0 +1,109 @@
+package wini
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func inheritDialect() Dialect {
+	d := DefaultDialect
+	d.Inheritance = true
+	d.DefaultSection = "DEFAULT"
+	return d
+}
+
+const inheritSrc = `[DEFAULT]
+k = default
+d = default
+
+[c]
+k = c
+x = c
+
+[a : c]
+k = a
+
+[b]
+k = b
+x = b
+y = b
+
+[child : a : b]
+k = child
+k = child2
+`
+
+func TestResolve(t *testing.T) {
+	f, err := inheritDialect().ParseString(inheritSrc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	child := f.Section("child")
+	if got := child.Inherits(); !equalStrings(got, []string{"a", "b"}) {
+		t.Errorf("Inherits() = %q", got)
+	}
+	tests := []struct {
+		key string
+		val string
+		sec string
+	}{
+		{"k", "child2", "child"},
+		{"x", "c", "c"},
+		{"y", "b", "b"},
+		{"d", "default", "DEFAULT"},
+	}
+	for _, tt := range tests {
+		kv, err := child.Resolve(tt.key)
+		if err != nil || kv.Val() != tt.val || kv.Section().Name() != tt.sec {
+			t.Errorf("Resolve(%v) = %v,%v", tt.key, kv, err)
+		}
+	}
+	if _, err := child.Resolve("none"); !errors.Is(err, ErrNoKey) {
+		t.Errorf("Resolve(none) error = %v", err)
+	}
+	data, err := child.EffectiveData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"k": "child2", "x": "c", "y": "b", "d": "default"}
+	if len(data) != len(want) {
+		t.Errorf("EffectiveData() = %v", data)
+	}
+	for k, v := range want {
+		if data[k] != v {
+			t.Errorf("EffectiveData()[%v] = %q, want %q", k, data[k], v)
+		}
+	}
+}
+
+func TestResolveErrors(t *testing.T) {
+	f, _ := inheritDialect().ParseString("[a : b]\n[b : c]\n[c : a]\n[d : none]\n")
+	_, err := f.Section("a").Resolve("k")
+	if !errors.Is(err, ErrInheritCycle) || !strings.Contains(err.Error(), "a -> b -> c -> a") {
+		t.Errorf("cycle error = %v", err)
+	}
+	if _, err := f.Section("d").Resolve("k"); !errors.Is(err, ErrNoSection) {
+		t.Errorf("missing base error = %v", err)
+	}
+	if _, err := f.Section("d").EffectiveData(); !errors.Is(err, ErrNoSection) {
+		t.Errorf("EffectiveData error = %v", err)
+	}
+}
+
+func TestInheritanceOff(t *testing.T) {
+	f, _ := ParseString("[a : b]\n")
+	if f.Section("a : b") == nil || len(f.Section("a : b").Inherits()) != 0 {
+		t.Errorf("sections = %q", secNamesOf(f.secs))
+	}
+}
+
+func TestRenameKeepsBases(t *testing.T) {
+	f, _ := inheritDialect().ParseString("[b]\n[a:b]\nk=v\n")
+	if err := f.RenameSection("a", "x"); err != nil {
+		t.Fatal(err)
+	}
+	if got := f.text(); got != "[b]\n[x : b]\nk=v\n" {
+		t.Errorf("text() = %q", got)
+	}
+}
@@ -1,72 +1,178 @@
-package main
+package wini
 
 type (
-	keyval struct {
+	KeyVal struct {
 		block
-		key string
-		val string
+		key   string
+		val   string
+		raw   string   //value as written in the line.
+		noVal bool     //key without separator, like `skip-networking`.
+		sec   *Section //section it belongs to. <nil> when not added yet.
+		style kvStyle  //layout of the line.
 	}
 
-	KeyVals []*keyval
+	KeyVals []*KeyVal
 )
 
-func NewKeyVal(key, val string) *keyval {
+//Creates key-val data written in DefaultDialect.
+//When added to a section, it is rewritten in the Dialect of the section.
+//An empty `val` is written as `key=`. See NewFlag for `key`.
+func NewKeyVal(key, val string) *KeyVal {
+	return newKeyVal(defaultDialect(), key, val)
+}
+
+//Creates key without value, written as `key` without separator.
+//It is used for flags like `skip-networking` of my.cnf.
+func NewFlag(key string) *KeyVal {
+	return newFlag(defaultDialect(), key)
+}
+
+func newKeyVal(d *Dialect, key, val string) *KeyVal {
 	key = trimSpaces(key)
-	val = trimSpaces(val)
-	text := genKeyValText(key, val)
+	val, raw := d.genRaw(d.trimVal(val), kvStyle{})
+	text := d.genKeyValText(key, raw, false, "", kvStyle{})
 	l := &lnode{}
 	l.setType(KEYVAL)
 	l.setIdentifier(key)
 	l.setText(text)
-	kv := &keyval{key: key, val: val}
+	kv := &KeyVal{key: key, val: val, raw: raw}
 	kv.ptr = l
+	kv.d = d
+	return kv
+}
+
+func newFlag(d *Dialect, key string) *KeyVal {
+	kv := newKeyVal(d, key, "")
+	kv.noVal = true
+	kv.update(kv.key, kv.val)
 	return kv
 }
 
-func (kv *keyval) Ptr() *lnode {
+func (kv *KeyVal) Ptr() *lnode {
 	return kv.ptr
 }
 
 //Returns the head and the tail of `KeyVal`
-func (kv *keyval) Range() (*lnode, *lnode) {
+func (kv *KeyVal) Range() (*lnode, *lnode) {
 	h, t := headBlock(kv), tailBlock(kv)
 	return h, t
 }
 
-func (kv *keyval) ChangeKey(key string) *keyval {
+//Changes the key. Spaces aligning the separator are adjusted,
+//so that it stays in the same column.
+func (kv *KeyVal) ChangeKey(key string) *KeyVal {
 	key = trimSpaces(key)
+	kv.style = kv.style.alignedTo(kv.style, kv.key, key)
 	//update underlying node.
 	kv.update(key, kv.val)
 	return kv
 }
 
-func (kv *keyval) ChangeVal(val string) *keyval {
-	val = trimSpaces(val)
+//Changes the value. A key without value gets the separator.
+func (kv *KeyVal) ChangeVal(val string) *KeyVal {
+	val = kv.d.trimVal(val)
+	kv.noVal = false
 	kv.update(kv.key, val)
 	return kv
 }
 
-func (kv *keyval) ChangeKeyVal(key, val string) *keyval {
+//Changes the key and the value. A key without value gets the separator.
+func (kv *KeyVal) ChangeKeyVal(key, val string) *KeyVal {
 	key = trimSpaces(key)
-	val = trimSpaces(val)
+	val = kv.d.trimVal(val)
+	kv.style = kv.style.alignedTo(kv.style, kv.key, key)
+	kv.noVal = false
 	kv.update(key, val)
 	return kv
 }
 
+//Removes the value and the separator, so that `keyval` is written as `key`.
+func (kv *KeyVal) RemoveVal() *KeyVal {
+	kv.noVal = true
+	kv.update(kv.key, "")
+	return kv
+}
+
 //Returns the `val` field.
-func (kv *keyval) Val() string {
+//In inline comment mode, inline comment is not included.
+//In quoting mode, quotes are removed and escapes are read.
+//It is "" for both `key` and `key=`. See HasValue.
+func (kv *KeyVal) Val() string {
 	return kv.val
 }
 
-func (kv *keyval) AddCom(texts ...string) *keyval {
-	kv.block.addCom(kv.ptr, KEYCOM, kv.key, texts...)
-	return kv
+//Returns the value as written in the line, such as `\#fff` for `#fff`
+//in inline comment mode, or `"a\tb"` in quoting mode.
+//Outer white spaces are not included.
+func (kv *KeyVal) Raw() string {
+	return kv.raw
+}
+
+//Reports whether `keyval` has the separator.
+//It is false for `key`, and true for `key=` and `key=val`.
+func (kv *KeyVal) HasValue() bool {
+	return !kv.noVal
+}
+
+//Returns the section `keyval` is in. Returns <nil> when not added to one.
+//For keyvals returned by Section.Resolve, it tells where the value came from.
+func (kv *KeyVal) Section() *Section {
+	return kv.sec
+}
+
+//Changes inline comment of `keyval`. Pass "" to remove it.
+//`text` must start with a comment symbol.
+//Inline comments are read back only in inline comment mode. See SetInlineCom.
+func (kv *KeyVal) ChangeInlineCom(text string) error {
+	if err := kv.d.checkInlineCom(text); err != nil {
+		return err
+	}
+	if text == "" && kv.inline != "" {
+		//white spaces before the removed comment are not needed.
+		kv.style.postVal = ""
+	}
+	kv.inline = text
+	kv.update(kv.key, kv.val)
+	return nil
+}
+
+//Adds comment(s) before `keyval`.
+//Each text must start with a comment symbol.
+func (kv *KeyVal) AddCom(texts ...string) error {
+	return kv.block.addCom(kv.ptr, KEYCOM, kv.key, texts...)
 }
 
-func (kv *keyval) update(key, val string) {
+//Rewrites the line in the layout it was read in.
+//The value is quoted when the line was.
+func (kv *KeyVal) update(key, val string) {
 	updateIdentifier(kv.ptr, key)
 	kv.key = key
-	kv.val = val
-	newName := genKeyValText(key, val)
-	kv.ptr.setText(newName)
+	kv.val, kv.raw = val, val
+	if !kv.noVal {
+		kv.val, kv.raw = kv.d.genRaw(val, kv.style)
+	}
+	text := kv.d.genKeyValText(key, kv.raw, kv.noVal, kv.inline, kv.style)
+	kv.ptr.setText(text)
+}
+
+//Writes `kv` in the layout of its neighbour `nb`,
+//unless `kv` was read from a line.
+func (kv *KeyVal) styleAfter(nb *KeyVal) {
+	if kv.style.read || nb == nil {
+		return
+	}
+	kv.style = kv.style.alignedTo(nb.style, nb.key, kv.key)
+	kv.update(kv.key, kv.val)
+}
+
+//Sets Dialect of `keyval`.
+//The line is rewritten when it was written in another Dialect.
+func (kv *KeyVal) setDialect(d *Dialect) {
+	if kv.d.same(d) {
+		kv.d = d
+	} else {
+		kv.d = d
+		kv.update(kv.key, kv.val)
+	}
+	kv.comments.setDialect(d)
 }
@@ -0,0 +1,102 @@
+package wini
+
+import (
+	"testing"
+)
+
+func TestEditKeepsStyle(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		edit func(kv *KeyVal)
+		want string
+	}{
+		{"aligned value", "[a]\nHome     = SAKURA-VPS\n", func(kv *KeyVal) { kv.ChangeVal("NEW") }, "[a]\nHome     = NEW\n"},
+		{"aligned key", "[a]\nHome     = SAKURA-VPS\n", func(kv *KeyVal) { kv.ChangeKey("Host") }, "[a]\nHost     = SAKURA-VPS\n"},
+		{"longer key", "[a]\nHome     = v\n", func(kv *KeyVal) { kv.ChangeKey("Location") }, "[a]\nLocation = v\n"},
+		{"indent", "[a]\n  Home=v\n", func(kv *KeyVal) { kv.ChangeKeyVal("Host", "w") }, "[a]\n  Host=w\n"},
+		{"quotes", "[a]\nHome = \"v\"\n", func(kv *KeyVal) { kv.ChangeVal("w") }, "[a]\nHome = \"w\"\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, _ := ParseString(tt.src)
+			tt.edit(f.Section("a").data[0])
+			if got := f.text(); got != tt.want {
+				t.Errorf("text() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddedKeyCopiesStyle(t *testing.T) {
+	f, _ := ParseString("[a]\n  Home     = v\n  Name     = w\n")
+	sec := f.Section("a")
+	sec.AddKeyVal(NewKeyVal("Age", "1"))
+	sec.AddValue("Home", "x")
+	want := "[a]\n  Home     = v\n  Home     = x\n  Name     = w\n  Age      = 1\n"
+	if got := f.text(); got != want {
+		t.Errorf("text() = %q, want %q", got, want)
+	}
+}
+
+func TestValuelessKeys(t *testing.T) {
+	src := "[mysqld]\nskip-networking\nempty=\nport=3306\n"
+	f, _ := ParseString(src)
+	sec := f.Section("mysqld")
+	if kv := sec.Key("skip-networking"); kv.HasValue() || kv.Val() != "" {
+		t.Errorf("flag HasValue() = %v", kv.HasValue())
+	}
+	if kv := sec.Key("empty"); !kv.HasValue() || kv.Val() != "" {
+		t.Errorf("empty HasValue() = %v", kv.HasValue())
+	}
+	if b, err := sec.Bool("skip-networking"); !b || err != nil {
+		t.Errorf("Bool(flag) = %v,%v", b, err)
+	}
+	if f.text() != src {
+		t.Errorf("text() = %q", f.text())
+	}
+
+	sec.Key("skip-networking").ChangeVal("1")
+	sec.Key("port").RemoveVal()
+	sec.AddKeyVal(NewFlag("skip-grant-tables"))
+	want := "[mysqld]\nskip-networking=1\nempty=\nport\nskip-grant-tables\n"
+	if got := f.text(); got != want {
+		t.Errorf("text() = %q, want %q", got, want)
+	}
+	if sec.Key("port").HasValue() || !sec.Key("skip-networking").HasValue() {
+		t.Error("HasValue() not updated")
+	}
+}
+
+func TestValueAfterFirstSeparator(t *testing.T) {
+	tests := []struct {
+		line string
+		key  string
+		val  string
+		raw  string
+	}{
+		{"a = b = c", "a", "b = c", "b = c"},
+		{"url = http://x/?q=1&r=2", "url", "http://x/?q=1&r=2", "http://x/?q=1&r=2"},
+		{"b64 = aGk=  ", "b64", "aGk=", "aGk="},
+		{"expr=x == y", "expr", "x == y", "x == y"},
+	}
+	for _, tt := range tests {
+		f, _ := ParseString(tt.line + "\n")
+		kv := f.Global().Key(tt.key)
+		if kv == nil || kv.Val() != tt.val || kv.Raw() != tt.raw {
+			t.Errorf("%q: Key(%q) = %v", tt.line, tt.key, kv)
+			continue
+		}
+		if f.text() != tt.line+"\n" {
+			t.Errorf("text() = %q", f.text())
+		}
+	}
+}
+
+func TestRawValue(t *testing.T) {
+	f, _ := inlineDialect().ParseString("color = \\#fff = x # c\n")
+	kv := f.Global().Key("color")
+	if kv.Val() != "#fff = x" || kv.Raw() != "\\#fff = x" {
+		t.Errorf("Val() = %q, Raw() = %q", kv.Val(), kv.Raw())
+	}
+}
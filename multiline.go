@@ -0,0 +1,125 @@
+//Values continued over several lines.
+
+package wini
+
+import (
+	"strings"
+)
+
+//Continuation styles of multi-line values.
+const (
+	contNone      = iota
+	contBackslash //lines end with "\".
+	contIndent    //following lines are indented.
+)
+
+//Reports whether `line` continues the value of node `prev`.
+func (d *Dialect) continues(prev *lnode, line string) bool {
+	if prev.ntype != KEYVAL {
+		return false
+	}
+	tm := strings.Trim(line, " \t")
+	if d.LineContinuation && endsWithBackslash(lastLine(prev.text)) {
+		return tm != ""
+	}
+	if d.IndentContinuation && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+		return tm != "" && !d.isComment(tm)
+	}
+	return false
+}
+
+//Returns the last line of multi-line `text`.
+func lastLine(text string) string {
+	return strings.TrimSuffix(text[strings.LastIndex(text, "\n")+1:], "\r")
+}
+
+func endsWithBackslash(line string) bool {
+	return strings.HasSuffix(strings.TrimRight(line, " \t"), "\\")
+}
+
+//Returns multi-line value `raw` joined into one line.
+//Lines ending with "\" are joined with a space, and indented lines with "\n".
+func (d *Dialect) joinLines(raw string) string {
+	if !strings.Contains(raw, "\n") {
+		return raw
+	}
+	lines := strings.Split(raw, "\n")
+	for i, l := range lines {
+		lines[i] = strings.Trim(strings.TrimSuffix(l, "\r"), " \t")
+	}
+	if !d.LineContinuation || !endsWithBackslash(lines[0]) {
+		return strings.Join(lines, "\n")
+	}
+	for i := range lines[:len(lines)-1] {
+		lines[i] = strings.TrimRight(strings.TrimSuffix(lines[i], "\\"), " \t")
+	}
+	return strings.Join(lines, " ")
+}
+
+//Reads continuation style of multi-line value `v` to `st`.
+func (d *Dialect) readCont(st *kvStyle, v string) {
+	i := strings.Index(v, "\n")
+	if i < 0 {
+		return
+	}
+	first := strings.TrimSuffix(v[:i], "\r")
+	st.contEOL = v[len(first) : i+1]
+	next := strings.TrimSuffix(v[i+1:], "\r")
+	if j := strings.Index(next, "\n"); j >= 0 {
+		next = next[:j]
+	}
+	st.contIndent = next[:len(next)-len(strings.TrimLeft(next, " \t"))]
+	if !d.LineContinuation || !endsWithBackslash(first) {
+		st.cont = contIndent
+		return
+	}
+	st.cont = contBackslash
+	tr := strings.TrimRight(first, " \t")
+	st.contMark = tr[len(strings.TrimRight(tr[:len(tr)-1], " \t")):]
+	for _, l := range strings.Split(v, "\n") {
+		l = strings.Trim(strings.TrimSuffix(l, "\r"), " \t")
+		l = strings.TrimRight(strings.TrimSuffix(l, "\\"), " \t")
+		if len(l) > st.width {
+			st.width = len(l)
+		}
+	}
+}
+
+//Writes `raw` over several lines in the continuation style of `st`.
+//Lines ending with "\" are wrapped at spaces, so that they are not much longer than they were.
+//In indented continuation mode, "\n" in `raw` starts a new indented line.
+func (d *Dialect) contLines(raw string, st kvStyle) string {
+	eol, indent := st.contEOL, st.contIndent
+	if eol == "" {
+		eol = "\n"
+	}
+	if indent == "" {
+		indent = "    "
+	}
+	if st.cont == contBackslash {
+		return strings.Join(wrapWords(raw, st.width), st.contMark+eol+indent)
+	}
+	if st.cont == contIndent || d.IndentContinuation {
+		return strings.Join(strings.Split(raw, "\n"), eol+indent)
+	}
+	return raw
+}
+
+//Splits `s` at spaces into lines up to `width` long.
+//Words longer than `width` make lines of their own.
+func wrapWords(s string, width int) []string {
+	lines := []string{}
+	cur := ""
+	for _, w := range strings.Split(s, " ") {
+		if len(cur) > 0 && len(cur)+1+len(w) > width {
+			lines = append(lines, cur)
+			cur = w
+			continue
+		}
+		if len(cur) > 0 {
+			cur += " "
+		}
+		cur += w
+	}
+	return append(lines, cur)
+}
@@ -0,0 +1,99 @@
+package wini
+
+import (
+	"testing"
+)
+
+func TestMultiLineValues(t *testing.T) {
+	back := DefaultDialect
+	back.LineContinuation = true
+	indent := DefaultDialect
+	indent.IndentContinuation = true
+	tests := []struct {
+		name string
+		d    Dialect
+		src  string
+		val  string
+		next string //value of key `n`,which follows the multi-line value.
+	}{
+		{"backslash", back, "[s]\nk = a \\\n    b \\\n    c\nn = 1\n", "a b c", "1"},
+		{"backslash crlf", back, "[s]\r\nk = a \\\r\n  b\r\nn = 1\r\n", "a b", "1"},
+		{"indent", indent, "[s]\nk =\n    a\n    b\nn = 1\n", "\na\nb", "1"},
+		{"indent with comment", indent, "[s]\nk = a\n    b\n    # c\nn = 1\n", "a\nb", "1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := tt.d.ParseString(tt.src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sec := f.Section("s")
+			if got := sec.Key("k").Val(); got != tt.val {
+				t.Errorf("Val() = %q, want %q", got, tt.val)
+			}
+			if got := sec.Key("n"); got == nil || got.Val() != tt.next {
+				t.Errorf("Key(n) = %v", got)
+			}
+			if f.text() != tt.src {
+				t.Errorf("text() = %q", f.text())
+			}
+		})
+	}
+}
+
+func TestEditMultiLineValues(t *testing.T) {
+	back := DefaultDialect
+	back.LineContinuation = true
+	indent := DefaultDialect
+	indent.IndentContinuation = true
+	tests := []struct {
+		name string
+		d    Dialect
+		src  string
+		val  string
+		want string
+	}{
+		{"backslash", back, "k = aa bb \\\n  cc\n", "aa bb cc dd", "k = aa bb \\\n  cc dd\n"},
+		{"backslash to one line", back, "k = aa bb \\\n  cc\n", "x", "k = x\n"},
+		{"indent", indent, "k = a\n\tb\n", "x\ny\nz", "k = x\n\ty\n\tz\n"},
+		{"new indented value", indent, "k = a\n", "x\ny", "k = x\n    y\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, _ := tt.d.ParseString(tt.src)
+			f.Global().Key("k").ChangeVal(tt.val)
+			if got := f.text(); got != tt.want {
+				t.Errorf("text() = %q, want %q", got, tt.want)
+			}
+			back, _ := tt.d.ParseString(f.text())
+			if got := back.Global().Key("k").Val(); got != tt.val {
+				t.Errorf("re-parsed Val() = %q", got)
+			}
+		})
+	}
+}
+
+//Formatted output does not double "\r" of lines inside a node.
+func TestFormattedCRLF(t *testing.T) {
+	indent := DefaultDialect
+	indent.IndentContinuation = true
+	block := DefaultDialect
+	block.BlockComment = [2]string{"/*", "*/"}
+	tests := []struct {
+		name string
+		d    Dialect
+		src  string
+		want string
+	}{
+		{"indented value", indent, "[a]\r\nk = x\r\n  y\r\n", "[a]\r\nk = x\r\n  y\r\n"},
+		{"block comment", block, "/* x\r\n y */\r\n[a]\r\nk = x\r\n", "/* x\r\n y */\r\n[a]\r\nk = x\r\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, _ := tt.d.ParseString(tt.src)
+			if got := f.formatted(0, 0, 0); got != tt.want {
+				t.Errorf("formatted() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,8 +1,8 @@
-package main
+package wini
 
 import (
 	"fmt"
-	"log"
+	"strconv"
 	"strings"
 )
 
@@ -13,41 +13,28 @@ const (
 	SECCOM           //Section Comment
 	KEYCOM           //KeyVal Comment
 	UNDEFINED        //other line
-)
-
-var (
-	commentSymbol = []string{"#", ";"}
-	sectionSymbol = []string{"[", "]"}
-	sepSymbol     = "="
+	GLOBAL           //Head of the global section. Not written out.
+	SECFOOT          //Section footer comment
+	FILEFOOT         //File footer comment
+	ARRAY            //Array of tables header, like [[name]]
 )
 
 type lnode struct {
 	ntype      int
 	identifier string
 	text       string
+	eol        string //line terminator as read. "" for new lines and the last line without one.
 	next       *lnode
 	prev       *lnode
 }
 
-func ChangeSepSym(ch string) {
-	sepSymbol = ch
-}
-
-func ChangeSectionSym(left, right string) {
-	sectionSymbol[0], sectionSymbol[1] = left, right
-}
-
-func AddCommentSym(ch string) {
-	commentSymbol = append(commentSymbol, ch)
-}
-
-func newLNode(l string) *lnode {
-	tp := which(l)
+func (d *Dialect) newLNode(l string) *lnode {
+	tp := d.which(l)
 	id := ""
-	if tp == SEC {
-		id = getSectionName(l)
+	if isSecType(tp) {
+		id = d.getSectionName(l)
 	} else if tp == KEYVAL {
-		id = getKeyName(l)
+		id = d.getKeyName(l)
 	}
 	return &lnode{
 		ntype:      tp,
@@ -138,28 +125,120 @@ func trimSpaces(line string) string {
 	return tm
 }
 
-func isComment(line string) bool {
+func (d *Dialect) isComment(line string) bool {
 	tm := trimSpaces(line)
 	if len(tm) == 0 {
 		return false
 	}
-	ch := tm[:1]
-	for _, v := range commentSymbol {
-		if v == ch {
+	for _, v := range d.CommentSymbols {
+		if v != "" && strings.HasPrefix(tm, v) {
 			return true
 		}
 	}
-	return false
+	return d.isBlockComOpen(tm)
+}
+
+//Reports whether `line` starts a block comment.
+func (d *Dialect) isBlockComOpen(line string) bool {
+	open := d.BlockComment[0]
+	return open != "" && strings.HasPrefix(trimSpaces(line), open)
 }
 
-func isSection(line string) bool {
+//Reports whether `line` starts a block comment that is not closed on the line.
+//Following lines are read as part of the comment until one of them closes it.
+func (d *Dialect) opensBlockCom(line string) bool {
+	if !d.isBlockComOpen(line) {
+		return false
+	}
 	tm := trimSpaces(line)
-	if len(tm) == 0 {
+	return !strings.Contains(tm[len(d.BlockComment[0]):], d.BlockComment[1])
+}
+
+//Reports whether block comment `text` ends with the close symbol.
+func (d *Dialect) isClosedBlockCom(text string) bool {
+	tm := trimSpaces(text)
+	open, close := d.BlockComment[0], d.BlockComment[1]
+	return len(tm) >= len(open)+len(close) && strings.HasSuffix(tm, close)
+}
+
+//Reports whether `line` closes a block comment.
+func (d *Dialect) closesBlockCom(line string) bool {
+	return strings.Contains(line, d.BlockComment[1])
+}
+
+//Reports whether node type `tp` is a section header, SEC or ARRAY.
+func isSecType(tp int) bool {
+	return tp == SEC || tp == ARRAY
+}
+
+func (d *Dialect) isSection(line string) bool {
+	return enclosed(trimSpaces(line), d.SectionSymbols[0], d.SectionSymbols[1])
+}
+
+//Reports whether `line` is an array of tables header like [[name]].
+//Only read when ArraySections of Dialect is on.
+func (d *Dialect) isArraySection(line string) bool {
+	left, right := d.secSyms(true)
+	return d.ArraySections && enclosed(trimSpaces(line), left, right)
+}
+
+//Reports whether `tm` starts with `left` and ends with `right`,
+//which is not escaped by "\".
+func enclosed(tm, left, right string) bool {
+	if len(tm) < len(left)+len(right) || !strings.HasPrefix(tm, left) || !strings.HasSuffix(tm, right) {
 		return false
 	}
-	left := sectionSymbol[0]
-	right := sectionSymbol[1]
-	return strings.HasPrefix(tm, left) && strings.HasSuffix(tm, right)
+	return !strings.HasSuffix(tm[len(left):len(tm)-len(right)], "\\")
+}
+
+//Returns section symbols. They are doubled for array of tables headers.
+func (d *Dialect) secSyms(array bool) (string, string) {
+	left, right := d.SectionSymbols[0], d.SectionSymbols[1]
+	if array {
+		return left + left, right + right
+	}
+	return left, right
+}
+
+//Returns the header line of section `name`.
+//Section symbols in `name` are escaped by "\".
+func (d *Dialect) secText(name string, array bool) string {
+	left, right := d.secSyms(array)
+	return left + d.escapeSecSym(name) + right
+}
+
+//Puts "\" before section symbols in `name`.
+func (d *Dialect) escapeSecSym(name string) string {
+	str := ""
+	for i := 0; i < len(name); i++ {
+		if d.hasSecSymAt(name, i) {
+			str += "\\"
+		}
+		str += name[i : i+1]
+	}
+	return str
+}
+
+//Removes "\" put before section symbols in `name`.
+func (d *Dialect) unescapeSecSym(name string) string {
+	str := ""
+	for i := 0; i < len(name); i++ {
+		if name[i] == '\\' && d.hasSecSymAt(name, i+1) {
+			continue
+		}
+		str += name[i : i+1]
+	}
+	return str
+}
+
+//Reports whether a section symbol starts at `name[i]`.
+func (d *Dialect) hasSecSymAt(name string, i int) bool {
+	for _, sym := range d.SectionSymbols {
+		if sym != "" && strings.HasPrefix(name[i:], sym) {
+			return true
+		}
+	}
+	return false
 }
 
 func isEmpty(line string) bool {
@@ -168,7 +247,7 @@ func isEmpty(line string) bool {
 	return tm == "\n" || tm == ""
 }
 
-func which(line string) int {
+func (d *Dialect) which(line string) int {
 	tm := trimSpaces(line)
 	if len(tm) == 0 {
 		return EMPTY
@@ -176,84 +255,441 @@ func which(line string) int {
 	if len(tm) == 1 && tm == "\n" {
 		return EMPTY
 	}
-	if isComment(tm) {
+	if d.isComment(tm) {
 		return UNDEFINED
 	}
-	if isSection(tm) {
+	if d.isArraySection(d.stripInlineCom(tm)) {
+		return ARRAY
+	}
+	if d.isSection(d.stripInlineCom(tm)) {
 		return SEC
 	}
 	return KEYVAL
 }
 
-func getSectionName(line string) string {
-	left, right := sectionSymbol[0], sectionSymbol[1]
-	tm := trimSpaces(line)
-	tm = strings.TrimLeft(tm, left)
-	tm = strings.TrimRight(tm, right)
-	return tm
+func (d *Dialect) getSectionName(line string) string {
+	name, _ := d.splitInherit(d.secBody(line))
+	return d.unescapeSecSym(name)
+}
+
+//Returns names of sections that section header `line` inherits from.
+func (d *Dialect) getBases(line string) []string {
+	_, bases := d.splitInherit(d.secBody(line))
+	return bases
+}
+
+//Returns section header `line` without section symbols.
+func (d *Dialect) secBody(line string) string {
+	tm := trimSpaces(d.stripInlineCom(line))
+	left, right := d.secSyms(d.isArraySection(tm))
+	return tm[len(left) : len(tm)-len(right)]
 }
 
-func getKeyName(line string) string {
-	k, _ := sepSplit(line)
+func (d *Dialect) getKeyName(line string) string {
+	k, _ := d.sepSplit(d.stripInlineCom(line))
 	return k
 }
 
-func genKeyValText(key, val string) string {
-	text := key
-	if len(val) > 0 {
-		text += sepSymbol + val
+//Splits `line` into its body and inline comment.
+//The inline comment starts with a comment symbol after a space or a tab,
+//which is not escaped by "\".
+//`com` is "" when there is none, or inline comment mode is off.
+func (d *Dialect) splitInlineCom(line string) (body, com string) {
+	if !d.InlineComment {
+		return line, ""
 	}
-	return text
+	for i := d.quotedEnd(line); i < len(line); i++ {
+		if line[i] == '\\' && d.hasComSymAt(line, i+1) {
+			i++ //skip escaped symbol.
+			continue
+		}
+		if i > 0 && (line[i-1] == ' ' || line[i-1] == '\t') && d.hasComSymAt(line, i) {
+			return strings.TrimRight(line[:i], " \t"), line[i:]
+		}
+	}
+	return line, ""
 }
 
-//Splits keyVal text by sepSymbol.
-func sepSplit(line string) (string, string) {
-	list := strings.Split(line, sepSymbol)
-	var k, v string
+//Returns the index after the quoted value of keyval `line` in quoting mode.
+//Comment symbols before it are in the value. Returns 0 when the value is not quoted.
+func (d *Dialect) quotedEnd(line string) int {
+	if !d.Quotes {
+		return 0
+	}
+	i := strings.Index(line, d.Separator)
+	if i < 0 {
+		return 0
+	}
+	start := i + len(d.Separator)
+	for start < len(line) && (line[start] == ' ' || line[start] == '\t') {
+		start++
+	}
+	if start >= len(line) || (line[start] != '"' && line[start] != '\'') {
+		return 0
+	}
+	q := line[start]
+	for j := start + 1; j < len(line); j++ {
+		if q == '"' && line[j] == '\\' {
+			j++ //skip escaped character.
+			continue
+		}
+		if line[j] == q {
+			return j + 1
+		}
+	}
+	return 0
+}
 
-	if len(list) == 1 {
-		k = trimSpaces(list[0])
-		v = ""
+//Returns the value of `raw`, which is the value as written in the line.
+//Quoted values are unquoted in quoting mode.
+func (d *Dialect) readVal(raw string) string {
+	if d.Quotes && len(raw) >= 2 {
+		switch raw[0] {
+		case '"':
+			if v, err := strconv.Unquote(raw); err == nil {
+				return v
+			}
+		case '\'':
+			if raw[len(raw)-1] == '\'' && !strings.Contains(raw[1:len(raw)-1], "'") {
+				//no escapes in single quotes.
+				return raw[1 : len(raw)-1]
+			}
+		}
+	}
+	return d.unescapeInlineCom(raw)
+}
 
-	} else if len(list) == 2 {
-		k, v = trimSpaces(list[0]), trimSpaces(list[1])
+//Returns `val` and the text of it written in the line, in layout `st`.
+//In quoting mode, `val` is quoted when it was, or when it needs to be.
+//Multi-line values are written in the continuation style of `st`.
+func (d *Dialect) genRaw(val string, st kvStyle) (string, string) {
+	if !d.Quotes {
+		val = st.quoted(val)
+		return val, d.contLines(d.escapeInlineCom(val), st)
+	}
+	if st.quote == "'" && !strings.ContainsAny(val, "'\r\n") {
+		return val, "'" + val + "'"
+	}
+	if st.quote != "" || d.needsQuote(val) {
+		return val, strconv.Quote(val)
+	}
+	return val, d.contLines(val, st)
+}
 
-	} else if len(list) > 2 {
-		k = trimSpaces(list[0])
-		for i := 1; i < len(list); i++ {
-			v += trimSpaces(list[i])
-			if i < len(list)-1 {
-				v += sepSymbol
-			}
+//Reports whether `val` should be quoted in quoting mode.
+//Values with comment symbols,outer white spaces, new lines,
+//or a quote at the head are quoted.
+//New lines are written as indented lines in indented continuation mode.
+func (d *Dialect) needsQuote(val string) bool {
+	if val != trimSpaces(val) || strings.Contains(val, "\r") {
+		return true
+	}
+	if strings.Contains(val, "\n") && !d.IndentContinuation {
+		return true
+	}
+	if strings.HasPrefix(val, "\"") || strings.HasPrefix(val, "'") {
+		return true
+	}
+	for _, sym := range d.CommentSymbols {
+		if strings.Contains(val, sym) {
+			return true
+		}
+	}
+	return false
+}
+
+//Reports whether `val`, written as a value in `d`, is read back as it is.
+//Without quoting, new lines start other lines and outer white spaces are trimmed.
+func (d *Dialect) readsBack(val string) bool {
+	kv := newKeyVal(d, "k", val)
+	lines := strings.Split(kv.ptr.text, "\n")
+	if d.which(lines[0]) != KEYVAL {
+		return false
+	}
+	l := &lnode{ntype: KEYVAL, text: lines[0]}
+	for _, line := range lines[1:] {
+		if !d.continues(l, line) {
+			return false
+		}
+		l.text += "\n" + line
+	}
+	text, _ := d.splitInlineCom(l.text)
+	_, raw := d.sepSplit(text)
+	return d.readVal(d.joinLines(raw)) == val
+}
+
+//Trims white spaces around `val`, unless in quoting mode,
+//where they are kept by quotes.
+func (d *Dialect) trimVal(val string) string {
+	if d.Quotes {
+		return val
+	}
+	return trimSpaces(val)
+}
+
+//Removes inline comment from `line`.
+func (d *Dialect) stripInlineCom(line string) string {
+	body, _ := d.splitInlineCom(line)
+	return body
+}
+
+//Appends inline comment `com` to `text`.
+func withInlineCom(text, com string) string {
+	if len(com) == 0 {
+		return text
+	}
+	return text + " " + com
+}
+
+//Puts "\" before comment symbols in `val` that would start an inline comment.
+func (d *Dialect) escapeInlineCom(val string) string {
+	if !d.InlineComment {
+		return val
+	}
+	str := ""
+	for i := 0; i < len(val); i++ {
+		if (i == 0 || val[i-1] == ' ' || val[i-1] == '\t') && d.hasComSymAt(val, i) {
+			str += "\\"
+		}
+		str += val[i : i+1]
+	}
+	return str
+}
+
+//Removes "\" put before comment symbols in `val`.
+func (d *Dialect) unescapeInlineCom(val string) string {
+	if !d.InlineComment {
+		return val
+	}
+	str := ""
+	for i := 0; i < len(val); i++ {
+		if val[i] == '\\' && d.hasComSymAt(val, i+1) {
+			continue
 		}
+		str += val[i : i+1]
 	}
-	return k, v
+	return str
 }
 
+//Reports whether a comment symbol starts at `line[i]`.
+func (d *Dialect) hasComSymAt(line string, i int) bool {
+	if i < 0 || i >= len(line) {
+		return false
+	}
+	for _, sym := range d.CommentSymbols {
+		if strings.HasPrefix(line[i:], sym) {
+			return true
+		}
+	}
+	return false
+}
+
+//Layout of a keyval line.
+//Edited lines are written again in the layout they were read in.
+type kvStyle struct {
+	read    bool   //read from a line.
+	indent  string //white spaces before the key.
+	preSep  string //white spaces before the separator.
+	postSep string //white spaces after the separator.
+	postVal string //white spaces after the value.
+	quote   string //quote around the value. "" when not quoted.
+
+	//multi-line values.
+	cont       int    //continuation style. contNone,contBackslash or contIndent.
+	contMark   string //" \" ending lines in contBackslash style.
+	contIndent string //indent of following lines.
+	contEOL    string //line terminator inside the value.
+	width      int    //longest line of the value in contBackslash style.
+}
+
+//Generates keyval line in `st`, followed by inline comment `com`.
+//The separator is left out when `noVal` is true.
+func (d *Dialect) genKeyValText(key, val string, noVal bool, com string, st kvStyle) string {
+	text := st.indent + key
+	if !noVal {
+		text += st.preSep + d.Separator + st.postSep + val
+	}
+	if len(com) > 0 && len(st.postVal) == 0 {
+		return withInlineCom(text, com)
+	}
+	return text + st.postVal + com
+}
+
+//Reads layout of keyval line `line`.
+func (d *Dialect) readStyle(line string) kvStyle {
+	st := kvStyle{read: true}
+	body, com := d.splitInlineCom(line)
+	if len(com) > 0 {
+		//white spaces before inline comment are trimmed from `body`.
+		st.postVal = line[len(body) : len(line)-len(com)]
+	} else {
+		trimmed := strings.TrimRight(body, " \t")
+		st.postVal = body[len(trimmed):]
+		body = trimmed
+	}
+	st.indent = body[:len(body)-len(strings.TrimLeft(body, " \t"))]
+	body = body[len(st.indent):]
+	i := strings.Index(body, d.Separator)
+	if i < 0 {
+		return st
+	}
+	k, v := body[:i], body[i+len(d.Separator):]
+	st.preSep = k[len(strings.TrimRight(k, " \t")):]
+	st.postSep = v[:len(v)-len(strings.TrimLeft(v, " \t"))]
+	v = v[len(st.postSep):]
+	d.readCont(&st, v)
+	if len(v) >= 2 && (v[0] == '"' || v[0] == '\'') && v[len(v)-1] == v[0] {
+		st.quote = v[:1]
+	}
+	return st
+}
+
+//Returns `val` enclosed by the quote of `st`, unless it already is.
+func (st kvStyle) quoted(val string) string {
+	q := st.quote
+	if len(q) == 0 || (len(val) >= 2 && strings.HasPrefix(val, q) && strings.HasSuffix(val, q)) {
+		return val
+	}
+	return q + val + q
+}
+
+//Returns `st` for keyval `key` written next to a keyval in `nb`.
+//The separator stays in the same column when `nb` aligns it with spaces.
+func (st kvStyle) alignedTo(nb kvStyle, nbKey, key string) kvStyle {
+	st.indent, st.preSep, st.postSep = nb.indent, nb.preSep, nb.postSep
+	if len(nb.preSep) > 1 && strings.Trim(nb.preSep, " ") == "" {
+		pad := len(nbKey) + len(nb.preSep) - len(key)
+		if pad < 1 {
+			pad = 1
+		}
+		st.preSep = strings.Repeat(" ", pad)
+	}
+	return st
+}
+
+//Splits keyVal text by the first Separator.
+//The value is everything after it, with only the outer white spaces trimmed,
+//so separators in the value are kept as they are.
+func (d *Dialect) sepSplit(line string) (string, string) {
+	i := strings.Index(line, d.Separator)
+	if i < 0 {
+		return trimSpaces(line), ""
+	}
+	return trimSpaces(line[:i]), trimSpaces(line[i+len(d.Separator):])
+}
+
+//Classifies comments as section comments,keyval comments, or footers.
+//Comments after the last keyval of a section are footers of the section,
+//when empty line(s) separate them from the next section.
+//Comments at the end of the file are footers of the section when they
+//directly follow its last keyval,and footers of the file otherwise.
 func classifyComments(l *lnode) {
-	cms := []*lnode{}
+	cms := []*lnode{} //comments and empty lines after `last`.
+	var last *lnode   //last section,keyval or global head node.
+	sec := ""         //name of the current section.
 	for l != nil {
-		if l.ntype == UNDEFINED {
+		if l.ntype == UNDEFINED || l.ntype == EMPTY {
 			cms = append(cms, l)
-		} else if l.ntype == SEC || l.ntype == KEYVAL {
+		} else if isSecType(l.ntype) {
+			at := lastEmpty(cms)
+			if last != nil {
+				classifyFooter(cms[:at+1], SECFOOT, sec)
+			}
+			_classifyComments(cms[at+1:], l)
+			cms = []*lnode{}
+			sec = l.identifier
+			last = l
+		} else if l.ntype == KEYVAL {
 			_classifyComments(cms, l)
 			cms = []*lnode{}
+			last = l
+		} else if l.ntype == GLOBAL {
+			cms = []*lnode{}
+			last = l
 		}
 		l = l.next
 	}
+	//comments at the end of the file.
+	at := 0
+	if last != nil && last.ntype != GLOBAL {
+		at = firstEmpty(cms)
+		classifyFooter(cms[:at], SECFOOT, sec)
+	}
+	classifyFooter(cms[at:], FILEFOOT, "")
 }
 
 func _classifyComments(cms []*lnode, l *lnode) {
 	var tp int
-	if l.ntype == SEC {
+	if isSecType(l.ntype) {
 		tp = SECCOM
 	} else if l.ntype == KEYVAL {
 		tp = KEYCOM
 	}
 	for _, c := range cms {
-		c.setType(tp)
-		c.setIdentifier(l.identifier)
+		if c.ntype == UNDEFINED {
+			c.setType(tp)
+			c.setIdentifier(l.identifier)
+		}
+	}
+}
+
+//Sets `ntype` and `id` to comments in `cms`.
+func classifyFooter(cms []*lnode, ntype int, id string) {
+	for _, c := range cms {
+		if c.ntype == UNDEFINED {
+			c.setType(ntype)
+			c.setIdentifier(id)
+		}
+	}
+}
+
+//Index of the first empty line in `ls`. Returns len(ls) when there is none.
+func firstEmpty(ls []*lnode) int {
+	for i, l := range ls {
+		if l.ntype == EMPTY {
+			return i
+		}
+	}
+	return len(ls)
+}
+
+//Index of the last empty line in `ls`. Returns -1 when there is none.
+func lastEmpty(ls []*lnode) int {
+	for i := len(ls) - 1; i >= 0; i-- {
+		if ls[i].ntype == EMPTY {
+			return i
+		}
+	}
+	return -1
+}
+
+//Links `g`, the head node of the global section, to the linked-list.
+//Comments at the top of the file are treated as comments of the global section,
+//when empty line(s) separate them from what follows, such as a license banner.
+//In that case `g` is linked right after them. Otherwise `g` becomes the head.
+//Returns the head of the linked-list.
+func classifyGlobal(l, g *lnode) *lnode {
+	n := l
+	for n != nil && n.ntype == EMPTY {
+		n = n.next
+	}
+	var last *lnode
+	for n != nil && n.ntype == UNDEFINED {
+		last = n
+		n = n.next
+	}
+	if last == nil || n == nil || n.ntype != EMPTY {
+		l.insertBefore(g)
+		return g
+	}
+	for c := l; c != n; c = c.next {
+		if c.ntype == UNDEFINED {
+			c.setType(SECCOM)
+			c.setIdentifier(g.identifier)
+		}
 	}
+	last.insert(g)
+	return l
 }
 
 //`l` should be the tail of the linked-list
@@ -272,22 +708,41 @@ func classifyEmptyLines(l *lnode) {
 	}
 }
 
-func checkComSym(text string) {
-	chk := string(text[0])
-	for _, ch := range commentSymbol {
-		if ch == chk {
-			return
+func (d *Dialect) checkComSym(text string) error {
+	if len(text) == 0 {
+		return fmt.Errorf("%w:%v", ErrComSym, text)
+	}
+	if d.isBlockComOpen(text) {
+		//a block comment must be closed.
+		if d.isClosedBlockCom(text) {
+			return nil
+		}
+		return fmt.Errorf("%w:%v", ErrComSym, text)
+	}
+	for _, ch := range d.CommentSymbols {
+		if ch != "" && strings.HasPrefix(text, ch) {
+			return nil
 		}
 	}
-	log.Fatal(fmt.Sprintf("lacking comment symbol:%v", text))
+	return fmt.Errorf("%w:%v", ErrComSym, text)
 }
 
-func checkSecSym(text string) {
-	left := string(text[0])
-	right := string(text[len(text)-1])
-	if left != sectionSymbol[0] || right != sectionSymbol[1] {
-		log.Fatal(fmt.Sprintf("lacking section symbol:%v", text))
+//Returns an error wrapping ErrSecSym when section `name`,written between section symbols,
+//is not read back as it is, like names with new lines,or inline comments in inline comment mode.
+func (d *Dialect) checkSecSym(name string, array bool) error {
+	if len(name) == 0 || strings.ContainsAny(name, "\r\n") {
+		return fmt.Errorf("%w:%q", ErrSecSym, name)
+	}
+	text := d.secText(name, array)
+	tp := d.which(text)
+	if !isSecType(tp) {
+		return fmt.Errorf("%w:%q", ErrSecSym, name)
+	}
+	//[[name]] is read as section "[name]" when ArraySections is off.
+	if (tp == ARRAY) == array && d.getSectionName(text) != name {
+		return fmt.Errorf("%w:%q", ErrSecSym, name)
 	}
+	return nil
 }
 
 // Used when changing section names or keyval key name.
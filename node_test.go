@@ -0,0 +1,207 @@
+package wini
+
+import (
+	"strings"
+	"testing"
+)
+
+func inlineDialect() Dialect {
+	d := DefaultDialect
+	d.InlineComment = true
+	return d
+}
+
+func TestInlineComments(t *testing.T) {
+	tests := []struct {
+		line string
+		key  string
+		val  string
+		com  string
+	}{
+		{"Hobby = Fishing # inline", "Hobby", "Fishing", "# inline"},
+		{"Hobby = Fishing ; inline", "Hobby", "Fishing", "; inline"},
+		{"Color = \\#fff", "Color", "#fff", ""},
+		{"Color = a#b", "Color", "a#b", ""},
+		{"Color = \\#fff # white", "Color", "#fff", "# white"},
+		{"Empty = # none", "Empty", "", "# none"},
+	}
+	d := inlineDialect()
+	for _, tt := range tests {
+		f, _ := d.ParseString("[a]\n" + tt.line + "\n")
+		kv := f.Section("a").Key(tt.key)
+		if kv == nil {
+			t.Errorf("%q: key not found", tt.line)
+			continue
+		}
+		if kv.Val() != tt.val || kv.InlineCom() != tt.com {
+			t.Errorf("%q: Val() = %q, InlineCom() = %q", tt.line, kv.Val(), kv.InlineCom())
+		}
+	}
+
+	//off by default.
+	f, _ := ParseString("[a]\nHobby = Fishing # inline\n")
+	if got := f.Section("a").Key("Hobby").Val(); got != "Fishing # inline" {
+		t.Errorf("Val() with inline comments off = %q", got)
+	}
+}
+
+func TestEditInlineComments(t *testing.T) {
+	d := inlineDialect()
+	f, _ := d.ParseString("[a] ; sec\nHobby = Fishing # inline\n")
+	kv := f.Section("a").Key("Hobby")
+	kv.ChangeVal("#1 Golf")
+	if got := kv.Ptr().text; got != "Hobby = \\#1 Golf # inline" {
+		t.Errorf("ChangeVal wrote %q", got)
+	}
+	if err := kv.ChangeInlineCom(""); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Section("a").ChangeInlineCom("# renamed"); err != nil {
+		t.Fatal(err)
+	}
+	f.ChangeSectionName("a", "b")
+	if got := f.text(); got != "[b] # renamed\nHobby = \\#1 Golf\n" {
+		t.Errorf("text() = %q", got)
+	}
+	if err := kv.ChangeInlineCom("no symbol"); err == nil {
+		t.Error("ChangeInlineCom without comment symbol succeeded")
+	}
+	f, _ = d.ParseString(f.text())
+	if got := f.Section("b").Key("Hobby").Val(); got != "#1 Golf" {
+		t.Errorf("read back Val() = %q", got)
+	}
+}
+
+func quoteDialect() Dialect {
+	d := DefaultDialect
+	d.Quotes = true
+	d.InlineComment = true
+	return d
+}
+
+func TestQuotedValues(t *testing.T) {
+	tests := []struct {
+		line string
+		val  string
+		raw  string
+	}{
+		{`k = "  padded  "`, "  padded  ", `"  padded  "`},
+		{`k = 'a\tb'`, `a\tb`, `'a\tb'`},
+		{`k = "a\tb\n\"c\"\\"`, "a\tb\n\"c\"\\", `"a\tb\n\"c\"\\"`},
+		{`k = "\u00e9"`, "é", `"\u00e9"`},
+		{`k = "# not a comment" # comment`, "# not a comment", `"# not a comment"`},
+		{`k = plain`, "plain", "plain"},
+	}
+	for _, tt := range tests {
+		f, err := quoteDialect().ParseString(tt.line + "\n")
+		if err != nil {
+			t.Fatal(err)
+		}
+		kv := f.Global().Key("k")
+		if kv.Val() != tt.val || kv.Raw() != tt.raw {
+			t.Errorf("%v: Val() = %q, Raw() = %q", tt.line, kv.Val(), kv.Raw())
+		}
+		if f.text() != tt.line+"\n" {
+			t.Errorf("text() = %q", f.text())
+		}
+	}
+}
+
+func TestChangeValQuotes(t *testing.T) {
+	tests := []struct {
+		line string
+		val  string
+		want string
+	}{
+		{"k = v", " x", `k = " x"`},
+		{"k = v", "a # b", `k = "a # b"`},
+		{"k = v", "a\nb", `k = "a\nb"`},
+		{"k = v", `"x`, `k = "\"x"`},
+		{"k = v", "x", "k = x"},
+		{"k = 'v'", "x", "k = 'x'"},
+		{`k = "v"`, "x", `k = "x"`},
+	}
+	for _, tt := range tests {
+		f, _ := quoteDialect().ParseString(tt.line + "\n")
+		kv := f.Global().Key("k")
+		kv.ChangeVal(tt.val)
+		if got := f.text(); got != tt.want+"\n" {
+			t.Errorf("ChangeVal(%q) = %q, want %q", tt.val, got, tt.want+"\n")
+		}
+		if kv.Val() != tt.val {
+			t.Errorf("Val() = %q", kv.Val())
+		}
+		back, _ := quoteDialect().ParseString(f.text())
+		if got := back.Global().Key("k").Val(); got != tt.val {
+			t.Errorf("re-parsed Val() = %q, want %q", got, tt.val)
+		}
+	}
+}
+
+func TestSectionSymbols(t *testing.T) {
+	angle := DefaultDialect
+	angle.SectionSymbols = [2]string{"<<", ">>"}
+	tests := []struct {
+		name string
+		d    Dialect
+		src  string
+		sec  string
+	}{
+		{"brackets in name", DefaultDialect, "[a\\]]\nk=v\n", "a]"},
+		{"escaped left", DefaultDialect, "[\\[x\\]]\nk=v\n", "[x]"},
+		{"multi-char", angle, "<<a>>\nk=v\n", "a"},
+		{"single char of multi-char", angle, "<<a>b>>\nk=v\n", "a>b"},
+		{"escaped multi-char", angle, "<<a\\>>b>>\nk=v\n", "a>>b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := tt.d.ParseString(tt.src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sec := f.Section(tt.sec)
+			if sec == nil || sec.Key("k") == nil {
+				t.Fatalf("section %q not found in %q", tt.sec, secNamesOf(f.secs))
+			}
+			if f.text() != tt.src {
+				t.Errorf("text() = %q", f.text())
+			}
+			//names are escaped when written.
+			g := tt.d.NewFile()
+			g.AddSec(newSection(&tt.d, tt.sec))
+			if got := g.text(); got != strings.SplitAfter(tt.src, "\n")[0] {
+				t.Errorf("added section = %q", got)
+			}
+		})
+	}
+}
+
+func TestArraySections(t *testing.T) {
+	d := DefaultDialect
+	d.ArraySections = true
+	d.Duplicates = DupError
+	src := "[a]\nk=0\n\n[[a]]\nk=1\n\n[[a]]\nk=2\n"
+	f, err := d.ParseString(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sec := f.Section("a"); sec == nil || sec.IsArray() || sec.Key("k").Val() != "0" {
+		t.Errorf("Section(a) = %v", sec)
+	}
+	arr := f.Array("a")
+	if len(arr) != 2 || !arr[0].IsArray() || arr[1].Key("k").Val() != "2" {
+		t.Fatalf("Array(a) = %v", arr)
+	}
+	f.AddSec(NewArraySection("a"))
+	arr[0].changeName("b")
+	want := "[a]\nk=0\n\n[[b]]\nk=1\n\n[[a]]\nk=2\n\n[[a]]\n"
+	if got := f.text(); got != want {
+		t.Errorf("text() = %q, want %q", got, want)
+	}
+
+	d.ArraySections = false
+	f, _ = d.ParseString("[[a]]\n")
+	if f.Section("[a]") == nil {
+		t.Errorf("sections = %q, want [a] when ArraySections is off", secNamesOf(f.secs))
+	}
+}
@@ -0,0 +1,118 @@
+package wini
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+//Dialects used by round-trip tests.
+func roundTripDialects() map[string]Dialect {
+	inline := DefaultDialect
+	inline.InlineComment = true
+	block := DefaultDialect
+	block.CommentSymbols = []string{"#", "//"}
+	block.BlockComment = [2]string{"/*", "*/"}
+	cont := DefaultDialect
+	cont.LineContinuation = true
+	cont.Quotes = true
+	merge := DefaultDialect
+	merge.Duplicates = DupMerge
+	return map[string]Dialect{
+		"default": DefaultDialect,
+		"inline":  inline,
+		"block":   block,
+		"cont":    cont,
+		"python":  PythonDialect,
+		"merge":   merge,
+	}
+}
+
+var roundTripTests = []struct {
+	name    string
+	dialect string
+	src     string
+}{
+	{"empty", "default", ""},
+	{"only newline", "default", "\n"},
+	{"lf", "default", "[a]\nk = v\n"},
+	{"crlf", "default", "[a]\r\nk = v\r\n\r\n[b]\r\nx=1\r\n"},
+	{"mixed eol", "default", "[a]\r\nk = v\n[b]\r\n"},
+	{"bom", "default", "\xef\xbb\xbf[a]\nk=v\n"},
+	{"bom crlf", "default", "\xef\xbb\xbf# c\r\n[a]\r\n"},
+	{"no final newline", "default", "[a]\nk = v"},
+	{"no final newline crlf", "default", "[a]\r\nk = v"},
+	{"trailing empty lines", "default", "[a]\nk=v\n\n\n"},
+	{"banner and global keys", "default", "# license\n# text\n\nport = 8080\nhost=x\n[s]\nx=1\n"},
+	{"global keys only", "default", "a=1\nb = 2\n"},
+	{"section footer", "default", "[a]\nk=v\n# foot of a\n\n[b]\nx=1\n"},
+	{"file footer", "default", "[a]\nk=v\n\n# end of file\n# really\n"},
+	{"indents and spacing", "default", "[a]\n  k   =   v  \n\tx=\ty\n"},
+	{"aligned keys", "default", "[a]\nname    = zen\nage     = 1\n"},
+	{"flag and empty value", "default", "[mysqld]\nskip-networking\nbind=\n"},
+	{"separator in value", "default", "[a]\nurl = http://x/?a=b\n"},
+	{"inline comments", "inline", "[a] ; sec\nk = v # com\ncolor = \\#fff\n"},
+	{"block comments", "block", "/* banner\n * more */\n\n// global\nk=v\n[s]\n  /* one */\n// two\na=1\n/* foot\n*/\n"},
+	{"block comment crlf", "block", "[s]\r\n/*\r\n[not a section]\r\n*/\r\na=1\r\n"},
+	{"backslash continuation", "cont", "[a]\nk = one \\\n    two \\\n    three\nx = \"  quoted  \"\n"},
+	{"indent continuation", "python", "[DEFAULT]\nk = one\n    two\n\n[a]\nx=1\n"},
+	{"duplicates", "default", "[s]\nx=1\n[t]\n[s]\nx=2\n"},
+	{"duplicates merged", "merge", "[s]\nx=1\n; c\n[t]\ny=2\n\n[s]\nx=3\n"},
+	{"repeated keys", "default", "[php]\nextension=gd\n; curl\nextension=curl\n"},
+}
+
+func TestRoundTrip(t *testing.T) {
+	dialects := roundTripDialects()
+	dir := t.TempDir()
+	for _, tt := range roundTripTests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := dialects[tt.dialect]
+			f, err := d.ParseString(tt.src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			if _, err := f.WriteTo(&buf); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != tt.src {
+				t.Errorf("WriteTo = %q, want %q", got, tt.src)
+			}
+			fpath := filepath.Join(dir, "rt.ini")
+			if err := f.Save(fpath); err != nil {
+				t.Fatal(err)
+			}
+			b, err := os.ReadFile(fpath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != tt.src {
+				t.Errorf("Save = %q, want %q", b, tt.src)
+			}
+			os.Remove(fpath)
+		})
+	}
+}
+
+//Edits keep line endings, BOM and the missing final newline of the rest of the file.
+func TestRoundTripAfterEdit(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"crlf", "[a]\r\nk = v\r\nx = 1\r\n", "[a]\r\nk = w\r\nx = 1\r\n"},
+		{"bom", "\xef\xbb\xbf[a]\nk=v\n", "\xef\xbb\xbf[a]\nk=w\n"},
+		{"no final newline", "[a]\nk = v", "[a]\nk = w"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, _ := ParseString(tt.src)
+			f.Section("a").Key("k").ChangeVal("w")
+			if got := f.text(); got != tt.want {
+				t.Errorf("text() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
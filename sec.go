@@ -1,4 +1,4 @@
-package main
+package wini
 
 import (
 	"fmt"
@@ -6,19 +6,53 @@ import (
 
 type Section struct {
 	block
-	data KeyVals
-	name string
+	footer
+	data  KeyVals
+	name  string
+	parts []*Section //later sections of the same name on DupMerge policy.
+	file  *File      //File the section is in. <nil> when not added to one.
+	bases []string   //sections it inherits from, like [child : parent].
 }
 
+//Creates section written in DefaultDialect.
+//`text` is the section name without section symbols.
+//When added to a file, it is rewritten in the Dialect of the file.
 func NewSection(text string) *Section {
-	// checkSecSym(text)
-	text = sectionSymbol[0] + text + sectionSymbol[1]
+	return newSection(defaultDialect(), text)
+}
+
+func newSection(d *Dialect, text string) *Section {
+	return _newSection(d, text, SEC)
+}
+
+//Creates an element of array of tables `text`, written as [[text]] in DefaultDialect.
+//Elements sharing a name are kept apart, whatever DupPolicy is.
+//Use File.Array to get them.
+func NewArraySection(text string) *Section {
+	return _newSection(defaultDialect(), text, ARRAY)
+}
+
+//`ntype` is SEC or ARRAY.
+func _newSection(d *Dialect, text string, ntype int) *Section {
 	l := &lnode{}
-	l.setType(SEC)
-	l.setIdentifier(getSectionName(text))
-	l.setText(text)
-	sec := &Section{name: getSectionName(text)}
+	l.setType(ntype)
+	l.setIdentifier(text)
+	l.setText(d.secText(text, ntype == ARRAY))
+	sec := &Section{name: text}
 	sec.ptr = l
+	sec.d = d
+	return sec
+}
+
+//Creates the nameless global section,
+//which holds key-val data and comments before the first section.
+//Its head node is not written out.
+func newGlobal(d *Dialect) *Section {
+	l := &lnode{}
+	l.setType(GLOBAL)
+	sec := &Section{}
+	sec.ptr = l
+	sec.d = d
 	return sec
 }
 
@@ -27,18 +61,56 @@ func (sec *Section) Ptr() *lnode {
 }
 
 //Pops `keyval` from `section`.
-//`key` is the key to pop.
+//`key` is the key to pop. Only the first one is popped when the key is repeated.
+//See PopNth and PopAll.
 func (s *Section) Pop(key string) {
 	kv := s.Key(key)
 	if kv != nil {
-		pop(kv)            //pop nodes from the linked-list.
-		s.popDataSlice(kv) //make sure to pop s.data.
+		s.popKeyVal(kv)
+	}
+}
+
+//Pops the `n`th keyval of `key`, counted from 0.
+//Does nothing when there are not so many.
+func (s *Section) PopNth(key string, n int) {
+	kvs := s.KeyAll(key)
+	if n >= 0 && n < len(kvs) {
+		s.popKeyVal(kvs[n])
+	}
+}
+
+//Pops all keyvals of `key`, with their comments.
+func (s *Section) PopAll(key string) {
+	for _, kv := range s.KeyAll(key) {
+		s.popKeyVal(kv)
+	}
+}
+
+//Pops `kv` from `section`.
+func (s *Section) popKeyVal(kv *KeyVal) {
+	pop(kv)            //pop nodes from the linked-list.
+	s.popDataSlice(kv) //make sure to pop s.data.
+	kv.sec = nil
+}
+
+//Inserts `kv` right after `at`, which is a keyval of `section`.
+func (s *Section) insertKeyVal(at, kv *KeyVal) {
+	kv.setDialect(s.d)
+	kv.styleAfter(at)
+	at.ptr.insertBlock(kv)
+	kv.sec = s
+	for i, v := range s.data {
+		if v == at {
+			s.data = append(s.data[:i+1], append(KeyVals{kv}, s.data[i+1:]...)...)
+			return
+		}
 	}
+	s.data = append(s.data, kv)
 }
 
 //Called from section.Pop()
 //Pops kv from section.data
-func (s *Section) popDataSlice(kv *keyval) {
+func (s *Section) popDataSlice(kv *KeyVal) {
 	at := -1
 	for i, v := range s.data {
 		if kv == v {
@@ -57,8 +129,9 @@ func (sec *Section) Range() (*lnode, *lnode) {
 	return h, t
 }
 
-//Searches key-val that matches `key`, and returns *keyval.
-func (s *Section) Key(key string) *keyval {
+//Searches key-val that matches `key`, and returns *KeyVal.
+//Returns the first one when the key is repeated. See KeyAll.
+func (s *Section) Key(key string) *KeyVal {
 	for _, kv := range s.data {
 		if kv.key == key {
 			return kv
@@ -67,13 +140,46 @@ func (s *Section) Key(key string) *keyval {
 	return nil //not found.
 }
 
+//Returns all keyvals that match `key`, in the order they appear.
+//Keys like `extension` of php.ini can be repeated.
+//Each of them has its own comments.
+func (s *Section) KeyAll(key string) KeyVals {
+	kvs := KeyVals{}
+	for _, kv := range s.data {
+		if kv.key == key {
+			kvs = append(kvs, kv)
+		}
+	}
+	return kvs
+}
+
+//Returns values of all keyvals that match `key`, in the order they appear.
+func (s *Section) Values(key string) []string {
+	vals := []string{}
+	for _, kv := range s.KeyAll(key) {
+		vals = append(vals, kv.val)
+	}
+	return vals
+}
+
+//Adds keyval of `key` and `val` after the last keyval of `key`,
+//even when there already is one, like `git config --add`.
+//It is added after the last keyval of `section` when there is no `key` yet.
+func (s *Section) AddValue(key, val string) *KeyVal {
+	kv := newKeyVal(s.d, key, val)
+	if kvs := s.KeyAll(kv.key); len(kvs) > 0 {
+		s.insertKeyVal(kvs[len(kvs)-1], kv)
+	} else {
+		s.AddKeyVal(kv)
+	}
+	return kv
+}
+
 //Returns all KeyVals under section as a "key"-"val" map.
-//Returns <nil> when no keyval is set.
+//Returns an empty map when no keyval is set.
 //It extracts only key-val data, discluding key-val comments.
+//The last value is taken for repeated keys. See Values.
 func (s *Section) Data() map[string]string {
-	if len(s.data) == 0 {
-		return nil
-	}
 	m := map[string]string{}
 	for _, kv := range s.data {
 		m[kv.key] = kv.val
@@ -82,11 +188,22 @@ func (s *Section) Data() map[string]string {
 }
 
 //Adds keyval to section.
-func (s *Section) AddKeyVal(kvs ...*keyval) *Section {
-	//var lastkv *keyval
+//They are added after the last keyval, before empty lines and footer comments.
+//New keyvals are written in the layout of the last keyval,
+//such as spaces around the separator and indents.
+func (s *Section) AddKeyVal(kvs ...*KeyVal) *Section {
+	//var lastkv *KeyVal
 	for _, kv := range kvs {
-		insertBlock(s, kv)
-		s.data = append(s.data, kv)
+		kv.setDialect(s.d)
+		if len(s.data) > 0 {
+			kv.styleAfter(s.data[len(s.data)-1])
+		}
+		s.lastBody().insertBlock(kv)
+		s.addKeyVals(kv)
+		//empty lines after the last keyval now follow `kv`.
+		for n := kv.ptr.next; n != nil && n.ntype == EMPTY; n = n.next {
+			n.setIdentifier(kv.key)
+		}
 		//lastkv = kv
 	}
 	//call this on last keyval.
@@ -97,14 +214,45 @@ func (s *Section) AddKeyVal(kvs ...*keyval) *Section {
 
 }
 
-func (s *Section) AddCom(texts ...string) *Section {
-	s.block.addCom(s.ptr, SECCOM, s.name, texts...)
-	return s
+//Adds comment(s) before `section`.
+//Each text must start with a comment symbol.
+func (s *Section) AddCom(texts ...string) error {
+	return s.block.addCom(s.ptr, SECCOM, s.name, texts...)
+}
+
+//Adds footer comment(s) after the last keyval of `section`.
+//Each text must start with a comment symbol.
+func (s *Section) AddFootCom(texts ...string) error {
+	//Footer comments directly follow the last keyval,
+	//so that they are not read as footer comments of the file.
+	return s.footer.addFootCom(s.d, s.lastBody(), SECFOOT, s.name, texts...)
+}
+
+//Pops all comments of `section`, including footer comments.
+//Comments of keyvals are left as they are.
+func (s *Section) PopAllCom() {
+	s.block.PopAllCom()
+	s.PopAllFootCom()
+	for _, p := range s.parts {
+		p.PopAllCom()
+	}
+}
+
+//Changes inline comment of `section`. Pass "" to remove it.
+//`text` must start with a comment symbol.
+//Inline comments are read back only in inline comment mode. See SetInlineCom.
+func (s *Section) ChangeInlineCom(text string) error {
+	if err := s.d.checkInlineCom(text); err != nil {
+		return err
+	}
+	s.inline = text
+	s.changeName(s.name)
+	return nil
 }
 
 //Swaps keyvals.`k1` and `k2` are keys of keyvals.
 func (s *Section) Swap(k1, k2 string) error {
-	var keyval1, keyval2 *keyval
+	var keyval1, keyval2 *KeyVal
 	for _, kv := range s.data {
 		if kv.key == k1 {
 			keyval1 = kv
@@ -129,15 +277,44 @@ func (s *Section) Swap(k1, k2 string) error {
 func (s *Section) changeName(name string) {
 	// Call this before changing name. Range() woul not work.
 	updateIdentifier(s.ptr, name)
+	for _, c := range s.foot {
+		c.ptr.setIdentifier(name)
+	}
 	s.name = name
-	left, right := sectionSymbol[0], sectionSymbol[1]
-	nameWithSym := left + name + right
 	//update the underlying node.
-	s.ptr.setText(nameWithSym)
+	s.ptr.setText(withInlineCom(s.d.secHeader(name, s.bases, s.IsArray()), s.inline))
+	for _, p := range s.parts {
+		p.changeName(name)
+	}
+}
+
+//Reports whether `section` is an element of array of tables, written like [[name]].
+func (s *Section) IsArray() bool {
+	return s.ptr.ntype == ARRAY
+}
+
+//Sets Dialect of `section` and its keyvals.
+//Lines are rewritten when they were written in another Dialect.
+func (s *Section) setDialect(d *Dialect) {
+	for _, p := range s.parts {
+		p.setDialect(d)
+	}
+	if s.d.same(d) || s.ptr.ntype == GLOBAL {
+		s.d = d
+	} else {
+		s.d = d
+		s.changeName(s.name)
+	}
+	s.comments.setDialect(d)
+	s.foot.setDialect(d)
+	for _, kv := range s.data {
+		kv.setDialect(d)
+	}
 }
 
-func (s *Section) addKeyVals(kvs ...*keyval) {
+func (s *Section) addKeyVals(kvs ...*KeyVal) {
 	for _, kv := range kvs {
+		kv.sec = s
 		s.data = append(s.data, kv)
 	}
 }
@@ -148,6 +325,7 @@ func (sec *Section) head() *lnode {
 }
 
 //Called from sec.Range()
+//Footer comments of the file are not included.
 func (sec *Section) tail() *lnode {
 	node := sec.ptr
 	for {
@@ -155,7 +333,7 @@ func (sec *Section) tail() *lnode {
 			break
 		}
 		np := node.next.ntype
-		if np == SEC || np == SECCOM {
+		if isSecType(np) || np == SECCOM || np == FILEFOOT {
 			break
 		}
 		node = node.next
@@ -163,11 +341,37 @@ func (sec *Section) tail() *lnode {
 	return node
 }
 
+//Returns the last node before footer comments.
+//New keyvals are inserted after it.
+func (sec *Section) bodyTail() *lnode {
+	node := sec.ptr
+	for {
+		if node.next == nil {
+			break
+		}
+		np := node.next.ntype
+		if isSecType(np) || np == SECCOM || np == FILEFOOT || np == SECFOOT {
+			break
+		}
+		node = node.next
+	}
+	return node
+}
+
+//Returns the last node before footer comments that is not an empty line.
+func (sec *Section) lastBody() *lnode {
+	t := sec.bodyTail()
+	for t != sec.ptr && t.ntype == EMPTY {
+		t = t.prev
+	}
+	return t
+}
+
 //Swaps empty-line and keyval.
 //Last node of section can be a empty line,
 //so added keyval could be inserted after it.
 //It looks better swapped...
-// func adjustEmptyLine(kv *keyval) {
+// func adjustEmptyLine(kv *KeyVal) {
 // 	h, t := kv.Range()
 // 	if h == t {
 // 		if t.ntype == KEYVAL && t.next != nil && t.prev != nil {
@@ -0,0 +1,63 @@
+package wini
+
+import (
+	"testing"
+)
+
+const multiSrc = `[PHP]
+; curl
+extension=curl
+memory_limit=128M
+; gd
+extension=gd
+`
+
+func TestRepeatedKeys(t *testing.T) {
+	f, _ := ParseString(multiSrc)
+	sec := f.Section("PHP")
+	if got := sec.Values("extension"); !equalStrings(got, []string{"curl", "gd"}) {
+		t.Errorf("Values() = %q", got)
+	}
+	kvs := sec.KeyAll("extension")
+	if len(kvs) != 2 || kvs[1].Com(0).Get() != "; gd" {
+		t.Fatalf("KeyAll() = %v", kvs)
+	}
+	if sec.Key("extension") != kvs[0] || sec.Data()["extension"] != "gd" {
+		t.Error("Key() should be the first one, and Data() the last one")
+	}
+	if len(sec.Values("missing")) != 0 || len(sec.KeyAll("missing")) != 0 {
+		t.Error("missing key has values")
+	}
+}
+
+func TestAddValue(t *testing.T) {
+	f, _ := ParseString(multiSrc)
+	sec := f.Section("PHP")
+	sec.AddValue("extension", "mbstring")
+	sec.AddValue("display_errors", "On")
+	want := "[PHP]\n; curl\nextension=curl\nmemory_limit=128M\n; gd\nextension=gd\nextension=mbstring\ndisplay_errors=On\n"
+	if got := f.text(); got != want {
+		t.Errorf("text() = %q, want %q", got, want)
+	}
+	if got := sec.Values("extension"); !equalStrings(got, []string{"curl", "gd", "mbstring"}) {
+		t.Errorf("Values() = %q", got)
+	}
+}
+
+func TestPopRepeatedKeys(t *testing.T) {
+	f, _ := ParseString(multiSrc)
+	sec := f.Section("PHP")
+	sec.PopNth("extension", 5)
+	sec.PopNth("extension", 1)
+	if got := f.text(); got != "[PHP]\n; curl\nextension=curl\nmemory_limit=128M\n" {
+		t.Errorf("text() after PopNth = %q", got)
+	}
+	sec.AddValue("extension", "gd")
+	sec.PopAll("extension")
+	if got := f.text(); got != "[PHP]\nmemory_limit=128M\n" {
+		t.Errorf("text() after PopAll = %q", got)
+	}
+	if len(sec.KeyAll("extension")) != 0 {
+		t.Error("PopAll left keyvals in data")
+	}
+}
@@ -0,0 +1,96 @@
+//Encoder and Decoder of ini streams.
+
+package wini
+
+import (
+	"bufio"
+	"io"
+)
+
+//Decoder reads ini text from an input stream.
+type Decoder struct {
+	r *bufio.Reader
+	d Dialect
+}
+
+//Returns a new Decoder that reads from `r` in DefaultDialect.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: bufio.NewReader(r), d: DefaultDialect}
+}
+
+//Makes the Decoder read in Dialect `d`.
+func (dec *Decoder) SetDialect(d Dialect) {
+	dec.d = d
+}
+
+//Reads all of the stream and stores it in `v`.
+//`v` is a pointer to struct, which is filled the same way as File.Unmarshal,
+//or a *File, which is set to the parsed File.
+//Returns io.EOF when there is nothing left to read,
+//such as when the stream was read by an earlier Decode. `v` is left as it is then.
+func (dec *Decoder) Decode(v any) error {
+	if _, err := dec.r.Peek(1); err == io.EOF {
+		return io.EOF
+	}
+	f, err := dec.d.Parse(dec.r)
+	if err != nil {
+		return err
+	}
+	if fp, ok := v.(*File); ok && fp != nil {
+		*fp = *f
+		//sections point to the File they are in.
+		fp.relink()
+		return nil
+	}
+	return f.Unmarshal(v)
+}
+
+//Encoder writes ini text to an output stream.
+type Encoder struct {
+	w         io.Writer
+	d         Dialect
+	formatted bool
+	secLines  int
+	kvLines   int
+	indent    int
+}
+
+//Returns a new Encoder that writes to `w` in DefaultDialect.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w, d: DefaultDialect}
+}
+
+//Makes the Encoder write structs in Dialect `d`.
+//A *File is written in its own Dialect.
+func (enc *Encoder) SetDialect(d Dialect) {
+	enc.d = d
+}
+
+//Makes the Encoder format the output the same way as Savef.
+//See Savef for the parameters.
+func (enc *Encoder) SetFormat(secLines, kvLines, indent int) {
+	enc.formatted = true
+	enc.secLines, enc.kvLines, enc.indent = secLines, kvLines, indent
+}
+
+//Writes `v` to the stream.
+//`v` is a struct or a pointer to struct, which is converted by Marshal,
+//or a *File. When the output is formatted, empty lines and indents of the *File are removed,
+//as Savef does.
+func (enc *Encoder) Encode(v any) error {
+	f, ok := v.(*File)
+	if !ok || f == nil {
+		var err error
+		if f, err = enc.d.Marshal(v); err != nil {
+			return err
+		}
+	}
+	var text string
+	if enc.formatted {
+		text = f.formatted(enc.secLines, enc.kvLines, enc.indent)
+	} else {
+		text = f.text()
+	}
+	_, err := io.WriteString(enc.w, text)
+	return err
+}
@@ -0,0 +1,89 @@
+package wini
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestDecodeFileSections(t *testing.T) {
+	var f File
+	src := "[a.b]\n[p]\ny=1\n[c : p]\n"
+	dec := NewDecoder(strings.NewReader(src))
+	d := DefaultDialect
+	d.Inheritance = true
+	dec.SetDialect(d)
+	if err := dec.Decode(&f); err != nil {
+		t.Fatal(err)
+	}
+	f.AddSec(NewSection("a"))
+	if p := f.Section("a.b").Parent(); p == nil || p.Name() != "a" {
+		t.Errorf("Parent() = %v", p)
+	}
+	if kv, err := f.Section("c").Resolve("y"); err != nil || kv.Section().Name() != "p" {
+		t.Errorf("Resolve = %v,%v", kv, err)
+	}
+	f.Pop("p")
+	if kv, err := f.Section("c").Resolve("y"); err == nil {
+		t.Errorf("Resolve through popped section = %v", kv.Val())
+	}
+}
+
+type streamConf struct {
+	Name string `ini:"name"`
+	S    struct {
+		Port int `ini:"port"`
+	} `ini:"s"`
+}
+
+func TestEncodeDecodeStruct(t *testing.T) {
+	var b strings.Builder
+	enc := NewEncoder(&b)
+	enc.SetDialect(ColonDialect)
+	c := streamConf{Name: "app"}
+	c.S.Port = 80
+	if err := enc.Encode(c); err != nil {
+		t.Fatal(err)
+	}
+	if want := "name:app\n\n[s]\nport:80\n"; b.String() != want {
+		t.Errorf("Encode = %q, want %q", b.String(), want)
+	}
+	var back streamConf
+	dec := NewDecoder(strings.NewReader(b.String()))
+	dec.SetDialect(ColonDialect)
+	if err := dec.Decode(&back); err != nil {
+		t.Fatal(err)
+	}
+	if back != c {
+		t.Errorf("Decode = %+v", back)
+	}
+}
+
+func TestEncodeFormatted(t *testing.T) {
+	f, _ := ParseString("# top\nname=app\n[s]\n\n\nport=80\nhost=x\n")
+	var b strings.Builder
+	enc := NewEncoder(&b)
+	enc.SetFormat(1, 0, 2)
+	if err := enc.Encode(f); err != nil {
+		t.Fatal(err)
+	}
+	if want := "# top\nname=app\n\n[s]\n  port=80\n  host=x\n"; b.String() != want {
+		t.Errorf("Encode = %q, want %q", b.String(), want)
+	}
+}
+
+func TestDecodeEOF(t *testing.T) {
+	dec := NewDecoder(strings.NewReader("name = app\n"))
+	var c streamConf
+	if err := dec.Decode(&c); err != nil || c.Name != "app" {
+		t.Fatalf("Decode = %+v,%v", c, err)
+	}
+	c.Name = "kept"
+	if err := dec.Decode(&c); err != io.EOF || c.Name != "kept" {
+		t.Errorf("second Decode = %+v,%v", c, err)
+	}
+	var f File
+	if err := NewDecoder(strings.NewReader("")).Decode(&f); err != io.EOF {
+		t.Errorf("Decode of empty stream error = %v", err)
+	}
+}
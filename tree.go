@@ -0,0 +1,117 @@
+//Sections forming a tree by their names.
+
+package wini
+
+//Splits section name `name` into its components.
+//"." and "/" split the name, like `server.http` and `a/b/c`.
+//A double-quoted part is one component, like `server "eu-west"` of git-config,
+//where "." and "/" do not split.
+//`\"` and `\\` are read as `"` and `\` in it.
+func splitSecName(name string) []string {
+	path := []string{}
+	cur := ""
+	quoted := false
+	add := func() {
+		if quoted {
+			path = append(path, cur)
+		} else if tm := trimSpaces(cur); tm != "" {
+			path = append(path, tm)
+		}
+		cur, quoted = "", false
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		switch {
+		case c == '"':
+			add()
+			end := i + 1
+			for ; end < len(name) && name[end] != '"'; end++ {
+				if name[end] == '\\' && end+1 < len(name) {
+					end++
+				}
+				cur += name[end : end+1]
+			}
+			quoted = true
+			add()
+			i = end
+		case c == '.' || c == '/':
+			add()
+		default:
+			cur += string(c)
+		}
+	}
+	add()
+	return path
+}
+
+//Reports whether `path` starts with `prefix` and is longer than it.
+func under(path, prefix []string) bool {
+	if len(path) <= len(prefix) {
+		return false
+	}
+	for i, c := range prefix {
+		if path[i] != c {
+			return false
+		}
+	}
+	return true
+}
+
+//Returns the name of `section` as written between section symbols.
+//It is "" for the global section.
+func (s *Section) Name() string {
+	return s.name
+}
+
+//Returns components of the section name.
+//`[server.http]`,`[server "http"]` and `[server/http]` are all ["server" "http"].
+func (s *Section) Path() []string {
+	return splitSecName(s.name)
+}
+
+//Returns the closest section above `section` in the tree of section names.
+//The parent of `[a.b.c]` is `[a.b]`, or `[a]` when there is no `[a.b]`.
+//Returns <nil> when there is none, or `section` is not in a File.
+func (s *Section) Parent() *Section {
+	if s.file == nil || s.ptr.ntype == GLOBAL {
+		return nil
+	}
+	path := s.Path()
+	var parent *Section
+	plen := 0
+	for _, sec := range s.file.secs {
+		p := sec.Path()
+		if len(p) > plen && under(path, p) {
+			parent, plen = sec, len(p)
+		}
+	}
+	return parent
+}
+
+//Returns sections whose Parent is `section`, in the order they appear.
+func (s *Section) Children() []*Section {
+	secs := []*Section{}
+	if s.file == nil {
+		return secs
+	}
+	for _, sec := range s.file.secs {
+		if sec != s && sec.Parent() == s {
+			secs = append(secs, sec)
+		}
+	}
+	return secs
+}
+
+//Returns all sections under `name` in the tree of section names, in the order they appear.
+//Sub("server") returns `[server.http]`,`[server "eu-west"]`,and `[server.http.tls]`,
+//but not `[server]` itself. It does not matter whether `[server]` is in the file.
+func (f *File) Sub(name string) []*Section {
+	prefix := splitSecName(name)
+	secs := []*Section{}
+	for _, sec := range f.secs {
+		if under(sec.Path(), prefix) {
+			secs = append(secs, sec)
+		}
+	}
+	return secs
+}
@@ -0,0 +1,86 @@
+package wini
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSplitSecName(t *testing.T) {
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"server", []string{"server"}},
+		{"server.http", []string{"server", "http"}},
+		{"a/b/c", []string{"a", "b", "c"}},
+		{`server "eu-west"`, []string{"server", "eu-west"}},
+		{`remote "a.b/c"`, []string{"remote", "a.b/c"}},
+		{`x "q\"\\"`, []string{"x", `q"\`}},
+		{"", []string{}},
+	}
+	for _, tt := range tests {
+		if got := splitSecName(tt.name); !equalStrings(got, tt.want) {
+			t.Errorf("splitSecName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+const treeSrc = `[server]
+[server.http]
+[server.http.tls]
+[server "eu-west"]
+[db/main]
+[serverx]
+`
+
+func TestSub(t *testing.T) {
+	f, _ := ParseString(treeSrc)
+	if got := secNamesOf(f.Sub("server")); got != `server.http,server.http.tls,server "eu-west"` {
+		t.Errorf("Sub(server) = %v", got)
+	}
+	if got := secNamesOf(f.Sub("db")); got != "db/main" {
+		t.Errorf("Sub(db) = %v", got)
+	}
+	if got := f.Sub("none"); len(got) != 0 {
+		t.Errorf("Sub(none) = %v", secNamesOf(got))
+	}
+}
+
+func TestParentAndChildren(t *testing.T) {
+	f, _ := ParseString(treeSrc)
+	tests := []struct {
+		sec      string
+		parent   string
+		children string
+	}{
+		{"server", "", `server.http,server "eu-west"`},
+		{"server.http", "server", "server.http.tls"},
+		{"server.http.tls", "server.http", ""},
+		{"db/main", "", ""},
+		{"serverx", "", ""},
+	}
+	for _, tt := range tests {
+		sec := f.Section(tt.sec)
+		parent := ""
+		if p := sec.Parent(); p != nil {
+			parent = p.Name()
+		}
+		if parent != tt.parent {
+			t.Errorf("Parent(%v) = %q, want %q", tt.sec, parent, tt.parent)
+		}
+		if got := secNamesOf(sec.Children()); got != tt.children {
+			t.Errorf("Children(%v) = %q, want %q", tt.sec, got, tt.children)
+		}
+	}
+	//the closest existing section is the parent.
+	f.Pop("server.http")
+	if p := f.Section("server.http.tls").Parent(); p == nil || p.Name() != "server" {
+		t.Errorf("Parent() after Pop = %v", p)
+	}
+	if NewSection("a.b").Parent() != nil {
+		t.Error("Parent() of a section not in a File")
+	}
+	if got := strings.Join(f.Section(`server "eu-west"`).Path(), ","); got != "server,eu-west" {
+		t.Errorf("Path() = %q", got)
+	}
+}
@@ -0,0 +1,288 @@
+//Writing edited structs back to File.
+
+package wini
+
+import (
+	"errors"
+	"reflect"
+)
+
+var errUpdateTarget = errors.New("wini: Update needs a struct or a non-nil pointer to struct")
+
+//State of File.Update.
+type updater struct {
+	f     *File
+	prune bool
+	secs  map[string]bool //sections that have a field.
+}
+
+//Writes struct `v` back to `file`, leaving its layout as it is.
+//Fields are mapped the same way as File.Unmarshal.
+//
+//Only values that changed are rewritten by ChangeVal,
+//so `Port = 0x10` is left as it is when the field is 16.
+//Keys and sections missing in `file` are added after the others,
+//with comments of `comment` tags as in Marshal.
+//A missing key is not added when its field holds what Unmarshal gives it,
+//which is the `default` tag, or the zero value when there is none.
+//Neither is a missing section that would have no key.
+//When `prune` is true, keys and sections that have no field in `v` are popped.
+//Nil pointers and nil maps count as no field.
+//Comments,empty lines and the order of untouched lines are kept.
+//Values are checked as in Marshal, before the key or the section is written.
+func (f *File) Update(v any, prune bool) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return errUpdateTarget
+	}
+	u := &updater{f: f, prune: prune, secs: map[string]bool{}}
+	if err := u.updateSection(f.Global(), rv); err != nil {
+		return err
+	}
+	if prune {
+		for i := len(f.secs) - 1; i >= 0; i-- {
+			if !u.secs[f.secs[i].name] {
+				f.popAt(i)
+			}
+		}
+	}
+	return nil
+}
+
+//Writes struct `rv` to `sec`.
+func (u *updater) updateSection(sec *Section, rv reflect.Value) error {
+	keys := map[string]bool{}
+	if err := u.updateStruct(sec, rv, keys); err != nil {
+		return err
+	}
+	u.pruneKeys(sec, keys)
+	return nil
+}
+
+//Writes fields of struct `rv` to `sec`. Keys with a field are set to `keys`.
+func (u *updater) updateStruct(sec *Section, rv reflect.Value, keys map[string]bool) error {
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		fv := rv.Field(i)
+		if sf.Anonymous && sf.Type.Kind() == reflect.Struct {
+			if err := u.updateStruct(sec, fv, keys); err != nil {
+				return err
+			}
+			continue
+		}
+		tag := parseFieldTag(sf)
+		if !sf.IsExported() || tag.name == "-" {
+			continue
+		}
+		if isSectionType(sf.Type) {
+			if err := u.updateSub(subSectionName(sec.name, tag.name), tag, fv); err != nil {
+				return err
+			}
+			continue
+		}
+		if fv.Kind() == reflect.Pointer && fv.IsNil() {
+			continue
+		}
+		keys[tag.name] = true
+		if err := updateKey(sec, tag, fv); err != nil {
+			var ve *ValueError
+			if errors.As(err, &ve) {
+				return err
+			}
+			return &ValueError{Section: sec.name, Key: tag.name, Type: sf.Type.String(), Err: err}
+		}
+	}
+	return nil
+}
+
+//Writes section `name` from `fv`, which is a struct or map[string]string field.
+//The section is added when missing.
+func (u *updater) updateSub(name string, tag fieldTag, fv reflect.Value) error {
+	if fv.Kind() == reflect.Pointer || fv.Kind() == reflect.Map {
+		if fv.IsNil() {
+			return nil
+		}
+	}
+	if fv.Kind() == reflect.Pointer {
+		fv = fv.Elem()
+	}
+	if fv.Kind() == reflect.Map {
+		if err := u.f.d.checkMap(name, fv); err != nil {
+			return err
+		}
+	}
+	sec := u.f.Section(name)
+	if sec == nil {
+		if !writesKeys(fv) {
+			//nothing to write, but sections under it may have something.
+			return u.updateSubs(name, fv)
+		}
+		coms, err := u.f.d.comTexts(tag.comments)
+		if err != nil {
+			return err
+		}
+		sec = newSection(u.f.d, name)
+		u.f.AddSec(sec)
+		if err := sec.AddCom(coms...); err != nil {
+			return err
+		}
+	}
+	u.secs[name] = true
+	if fv.Kind() == reflect.Map {
+		u.updateMap(sec, fv)
+		return nil
+	}
+	return u.updateSection(sec, fv)
+}
+
+//Writes sections under section `name` from struct `rv`, leaving section `name` out.
+func (u *updater) updateSubs(name string, rv reflect.Value) error {
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		fv := rv.Field(i)
+		if sf.Anonymous && sf.Type.Kind() == reflect.Struct {
+			if err := u.updateSubs(name, fv); err != nil {
+				return err
+			}
+			continue
+		}
+		tag := parseFieldTag(sf)
+		if !sf.IsExported() || tag.name == "-" || !isSectionType(sf.Type) {
+			continue
+		}
+		if err := u.updateSub(subSectionName(name, tag.name), tag, fv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//Reports whether map or struct `rv` has a key to write to a missing section.
+//Struct fields that hold what Unmarshal gives them for a missing key are not written.
+func writesKeys(rv reflect.Value) bool {
+	if rv.Kind() == reflect.Map {
+		return rv.Len() > 0
+	}
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		fv := rv.Field(i)
+		if sf.Anonymous && sf.Type.Kind() == reflect.Struct {
+			if writesKeys(fv) {
+				return true
+			}
+			continue
+		}
+		tag := parseFieldTag(sf)
+		if !sf.IsExported() || tag.name == "-" || isSectionType(sf.Type) {
+			continue
+		}
+		if fv.Kind() == reflect.Pointer && fv.IsNil() {
+			continue
+		}
+		if !isUnset(tag, fv) {
+			return true
+		}
+	}
+	return false
+}
+
+//Reports whether `fv` holds what Unmarshal gives a field of a missing key,
+//which is the `default` tag, or the zero value when there is none.
+func isUnset(tag fieldTag, fv reflect.Value) bool {
+	want := reflect.New(fv.Type()).Elem()
+	if tag.hasDef && setField(want, []string{tag.def}) != nil {
+		return false
+	}
+	return sameValue(want, fv)
+}
+
+//Writes map `fv` to `sec`. The last keyval of a key is changed,
+//as it is the one Data returns.
+func (u *updater) updateMap(sec *Section, fv reflect.Value) {
+	keys := map[string]bool{}
+	for _, k := range mapKeys(fv) {
+		keys[k] = true
+		val := fv.MapIndex(reflect.ValueOf(k).Convert(fv.Type().Key())).String()
+		kvs := sec.KeyAll(k)
+		if len(kvs) == 0 {
+			sec.AddKeyVal(newKeyVal(sec.d, k, val))
+		} else if kv := kvs[len(kvs)-1]; kv.val != val {
+			kv.ChangeVal(val)
+		}
+	}
+	u.pruneKeys(sec, keys)
+}
+
+//Pops keyvals of `sec` that are not in `keys`, when pruning.
+func (u *updater) pruneKeys(sec *Section, keys map[string]bool) {
+	if !u.prune {
+		return
+	}
+	for _, kv := range append(KeyVals{}, sec.data...) {
+		if !keys[kv.key] {
+			sec.popKeyVal(kv)
+		}
+	}
+}
+
+//Writes field `fv` to keyvals of `sec`, when the value changed.
+//Repeated keys stay repeated; extra ones are popped,and new ones follow the last one.
+func updateKey(sec *Section, tag fieldTag, fv reflect.Value) error {
+	kvs := sec.KeyAll(tag.name)
+	if len(kvs) == 0 && isUnset(tag, fv) {
+		return nil
+	}
+	if len(kvs) > 0 {
+		cur := reflect.New(fv.Type()).Elem()
+		if setField(cur, fieldVals(kvs, fv.Type())) == nil && sameValue(cur, fv) {
+			return nil
+		}
+	}
+	var vals []string
+	var err error
+	if len(kvs) > 1 && isList(fv) {
+		vals, err = formatList(fv)
+	} else {
+		vals, err = formatField(fv)
+	}
+	if err != nil {
+		return err
+	}
+	if v, ok := sec.d.unwritable(vals); ok {
+		return &ValueError{Section: sec.name, Key: tag.name, Val: v, Type: fv.Type().String(), Err: ErrUnwritable}
+	}
+	if len(kvs) == 0 {
+		return sec.marshalKey(tag, vals)
+	}
+	for i, kv := range kvs {
+		if i >= len(vals) {
+			sec.popKeyVal(kv)
+		} else if kv.val != vals[i] {
+			kv.ChangeVal(vals[i])
+		}
+	}
+	last := kvs[len(kvs)-1]
+	for i := len(kvs); i < len(vals); i++ {
+		kv := newKeyVal(sec.d, tag.name, vals[i])
+		sec.insertKeyVal(last, kv)
+		last = kv
+	}
+	return nil
+}
+
+//Reports whether `a` and `b` hold the same value. Empty slices are the same.
+func sameValue(a, b reflect.Value) bool {
+	if a.Kind() == reflect.Slice && a.Len() == 0 && b.Len() == 0 {
+		return true
+	}
+	return reflect.DeepEqual(a.Interface(), b.Interface())
+}
@@ -0,0 +1,50 @@
+package wini
+
+import (
+	"testing"
+)
+
+type updateConf struct {
+	S struct {
+		Port  int    `ini:"port"`
+		Host  string `ini:"host" default:"localhost"`
+		Debug bool   `ini:"debug"`
+	} `ini:"s"`
+	T *struct {
+		Name string `ini:"name"`
+	} `ini:"t"`
+	U struct {
+		Level int `ini:"level" default:"3"`
+	} `ini:"u"`
+}
+
+func TestUpdateUnchanged(t *testing.T) {
+	src := "[s]\nport = 8080\n"
+	f, _ := ParseString(src)
+	var c updateConf
+	if err := f.Unmarshal(&c); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Update(&c, false); err != nil {
+		t.Fatal(err)
+	}
+	if got := f.text(); got != src {
+		t.Errorf("Update without changes wrote %q", got)
+	}
+}
+
+func TestUpdateChanged(t *testing.T) {
+	f, _ := ParseString("[s]\nport = 8080\n")
+	var c updateConf
+	f.Unmarshal(&c)
+	c.S.Port = 9090
+	c.S.Debug = true
+	c.U.Level = 4
+	if err := f.Update(&c, false); err != nil {
+		t.Fatal(err)
+	}
+	want := "[s]\nport = 9090\ndebug = true\n\n[u]\nlevel=4\n"
+	if got := f.text(); got != want {
+		t.Errorf("text() = %q, want %q", got, want)
+	}
+}
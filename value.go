@@ -0,0 +1,206 @@
+//Typed getters and setters of key-val data.
+
+package wini
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+//Returns the value as int. "0x" and "0o" prefixes are accepted.
+//The returned error is a *ValueError.
+func (kv *KeyVal) Int() (int, error) {
+	i, err := strconv.ParseInt(kv.val, 0, strconv.IntSize)
+	if err != nil {
+		return 0, kv.valueError("int", err)
+	}
+	return int(i), nil
+}
+
+//Returns the value as float64.
+//The returned error is a *ValueError.
+func (kv *KeyVal) Float() (float64, error) {
+	f, err := strconv.ParseFloat(kv.val, 64)
+	if err != nil {
+		return 0, kv.valueError("float", err)
+	}
+	return f, nil
+}
+
+//Returns the value as bool.
+//"true","yes","on","1" are true,and "false","no","off","0" are false,
+//regardless of the case. A key without value, like `skip-networking`, is true.
+//The returned error is a *ValueError.
+func (kv *KeyVal) Bool() (bool, error) {
+	if kv.noVal {
+		return true, nil
+	}
+	b, err := parseBool(kv.val)
+	if err != nil {
+		return false, kv.valueError("bool", err)
+	}
+	return b, nil
+}
+
+//Returns the value as time.Duration, such as "1h30m" or "500ms".
+//The returned error is a *ValueError.
+func (kv *KeyVal) Duration() (time.Duration, error) {
+	d, err := time.ParseDuration(kv.val)
+	if err != nil {
+		return 0, kv.valueError("duration", err)
+	}
+	return d, nil
+}
+
+//Returns the value as time.Time. The value should be in RFC3339 format.
+//The returned error is a *ValueError.
+func (kv *KeyVal) Time() (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, kv.val)
+	if err != nil {
+		return time.Time{}, kv.valueError("time", err)
+	}
+	return t, nil
+}
+
+//Changes the value to `i`.
+func (kv *KeyVal) SetInt(i int) *KeyVal {
+	return kv.ChangeVal(strconv.Itoa(i))
+}
+
+//Changes the value to `f`,in the shortest form that reads back as `f`.
+func (kv *KeyVal) SetFloat(f float64) *KeyVal {
+	return kv.ChangeVal(strconv.FormatFloat(f, 'g', -1, 64))
+}
+
+//Changes the value to "true" or "false".
+func (kv *KeyVal) SetBool(b bool) *KeyVal {
+	return kv.ChangeVal(strconv.FormatBool(b))
+}
+
+//Changes the value to `d`, such as "1h30m0s".
+func (kv *KeyVal) SetDuration(d time.Duration) *KeyVal {
+	return kv.ChangeVal(d.String())
+}
+
+//Changes the value to `t` in RFC3339 format.
+func (kv *KeyVal) SetTime(t time.Time) *KeyVal {
+	return kv.ChangeVal(t.Format(time.RFC3339))
+}
+
+func parseBool(s string) (bool, error) {
+	switch strings.ToLower(s) {
+	case "true", "yes", "on", "1":
+		return true, nil
+	case "false", "no", "off", "0":
+		return false, nil
+	}
+	return false, strconv.ErrSyntax
+}
+
+func (kv *KeyVal) valueError(tp string, err error) error {
+	err = unwrapNumError(err)
+	name := ""
+	if kv.sec != nil {
+		name = kv.sec.name
+	}
+	return &ValueError{Section: name, Key: kv.key, Val: kv.raw, Type: tp, Err: err}
+}
+
+//Returns value of `key` as int. See KeyVal.Int.
+//The returned error is a *ValueError. It wraps ErrNoKey when `key` is not found.
+func (s *Section) Int(key string) (int, error) {
+	kv, err := s.typedKey(key, "int")
+	if err != nil {
+		return 0, err
+	}
+	return kv.Int()
+}
+
+//Returns value of `key` as float64. See KeyVal.Float.
+//The returned error is a *ValueError. It wraps ErrNoKey when `key` is not found.
+func (s *Section) Float(key string) (float64, error) {
+	kv, err := s.typedKey(key, "float")
+	if err != nil {
+		return 0, err
+	}
+	return kv.Float()
+}
+
+//Returns value of `key` as bool. See KeyVal.Bool.
+//The returned error is a *ValueError. It wraps ErrNoKey when `key` is not found.
+func (s *Section) Bool(key string) (bool, error) {
+	kv, err := s.typedKey(key, "bool")
+	if err != nil {
+		return false, err
+	}
+	return kv.Bool()
+}
+
+//Returns value of `key` as time.Duration. See KeyVal.Duration.
+//The returned error is a *ValueError. It wraps ErrNoKey when `key` is not found.
+func (s *Section) Duration(key string) (time.Duration, error) {
+	kv, err := s.typedKey(key, "duration")
+	if err != nil {
+		return 0, err
+	}
+	return kv.Duration()
+}
+
+//Returns value of `key` as time.Time. See KeyVal.Time.
+//The returned error is a *ValueError. It wraps ErrNoKey when `key` is not found.
+func (s *Section) Time(key string) (time.Time, error) {
+	kv, err := s.typedKey(key, "time")
+	if err != nil {
+		return time.Time{}, err
+	}
+	return kv.Time()
+}
+
+//Same as Int, but returns `def` when `key` is not found or cannot be read.
+func (s *Section) IntOr(key string, def int) int {
+	if i, err := s.Int(key); err == nil {
+		return i
+	}
+	return def
+}
+
+//Same as Float, but returns `def` when `key` is not found or cannot be read.
+func (s *Section) FloatOr(key string, def float64) float64 {
+	if f, err := s.Float(key); err == nil {
+		return f
+	}
+	return def
+}
+
+//Same as Bool, but returns `def` when `key` is not found or cannot be read.
+func (s *Section) BoolOr(key string, def bool) bool {
+	if b, err := s.Bool(key); err == nil {
+		return b
+	}
+	return def
+}
+
+//Same as Duration, but returns `def` when `key` is not found or cannot be read.
+func (s *Section) DurationOr(key string, def time.Duration) time.Duration {
+	if d, err := s.Duration(key); err == nil {
+		return d
+	}
+	return def
+}
+
+//Same as Time, but returns `def` when `key` is not found or cannot be read.
+func (s *Section) TimeOr(key string, def time.Time) time.Time {
+	if t, err := s.Time(key); err == nil {
+		return t
+	}
+	return def
+}
+
+func (s *Section) typedKey(key, tp string) (*KeyVal, error) {
+	kv := s.Key(key)
+	if kv == nil {
+		return nil, &ValueError{Section: s.name, Key: key, Type: tp, Err: ErrNoKey}
+	}
+	return kv, nil
+}
@@ -0,0 +1,118 @@
+package wini
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const valueSrc = `[v]
+int = 42
+hex = 0x10
+float = 1.5
+yes = Yes
+off = off
+flag
+dur = 1h30m
+time = 2024-01-02T03:04:05Z
+bad = abc
+`
+
+func TestTypedGetters(t *testing.T) {
+	f, _ := ParseString(valueSrc)
+	s := f.Section("v")
+	if i, err := s.Int("int"); i != 42 || err != nil {
+		t.Errorf("Int = %v,%v", i, err)
+	}
+	if i, err := s.Int("hex"); i != 16 || err != nil {
+		t.Errorf("Int(hex) = %v,%v", i, err)
+	}
+	if v, err := s.Float("float"); v != 1.5 || err != nil {
+		t.Errorf("Float = %v,%v", v, err)
+	}
+	for key, want := range map[string]bool{"yes": true, "off": false, "flag": true} {
+		if b, err := s.Bool(key); b != want || err != nil {
+			t.Errorf("Bool(%v) = %v,%v", key, b, err)
+		}
+	}
+	if d, err := s.Duration("dur"); d != 90*time.Minute || err != nil {
+		t.Errorf("Duration = %v,%v", d, err)
+	}
+	want := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	if tm, err := s.Time("time"); !tm.Equal(want) || err != nil {
+		t.Errorf("Time = %v,%v", tm, err)
+	}
+}
+
+func TestTypedGetterErrors(t *testing.T) {
+	f, _ := ParseString(valueSrc)
+	s := f.Section("v")
+	_, err := s.Int("bad")
+	var ve *ValueError
+	if !errors.As(err, &ve) {
+		t.Fatalf("Int error = %v, want *ValueError", err)
+	}
+	if ve.Section != "v" || ve.Key != "bad" || ve.Val != "abc" || !errors.Is(err, strconv.ErrSyntax) {
+		t.Errorf("ValueError = %+v", ve)
+	}
+	if _, err := s.Bool("missing"); !errors.Is(err, ErrNoKey) {
+		t.Errorf("Bool(missing) error = %v", err)
+	}
+	if _, err := s.Duration("bad"); !errors.As(err, &ve) {
+		t.Errorf("Duration error = %v", err)
+	}
+}
+
+func TestTypedGettersOr(t *testing.T) {
+	f, _ := ParseString(valueSrc)
+	s := f.Section("v")
+	if got := s.IntOr("missing", 7); got != 7 {
+		t.Errorf("IntOr(missing) = %v", got)
+	}
+	if got := s.IntOr("bad", 7); got != 7 {
+		t.Errorf("IntOr(bad) = %v", got)
+	}
+	if got := s.IntOr("int", 7); got != 42 {
+		t.Errorf("IntOr(int) = %v", got)
+	}
+	if got := s.FloatOr("bad", 2.5); got != 2.5 {
+		t.Errorf("FloatOr = %v", got)
+	}
+	if got := s.BoolOr("bad", true); !got {
+		t.Errorf("BoolOr = %v", got)
+	}
+	if got := s.DurationOr("missing", time.Second); got != time.Second {
+		t.Errorf("DurationOr = %v", got)
+	}
+	def := time.Unix(0, 0)
+	if got := s.TimeOr("bad", def); !got.Equal(def) {
+		t.Errorf("TimeOr = %v", got)
+	}
+}
+
+func TestTypedSetters(t *testing.T) {
+	f, _ := ParseString("[v]\na = x\nb = x\nc = x\nd = x\ne = x\n")
+	s := f.Section("v")
+	s.Key("a").SetInt(-3)
+	s.Key("b").SetFloat(0.1)
+	s.Key("c").SetBool(true)
+	s.Key("d").SetDuration(1500 * time.Millisecond)
+	s.Key("e").SetTime(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+	want := "[v]\na = -3\nb = 0.1\nc = true\nd = 1.5s\ne = 2024-01-02T03:04:05Z\n"
+	if got := f.text(); got != want {
+		t.Errorf("text() = %q, want %q", got, want)
+	}
+}
+
+//ValueError holds the value as written.
+func TestValueErrorRaw(t *testing.T) {
+	d := DefaultDialect
+	d.Quotes = true
+	f, _ := d.ParseString("n = \"4 2\"\n")
+	_, err := f.Global().Int("n")
+	var ve *ValueError
+	if !errors.As(err, &ve) || ve.Val != `"4 2"` {
+		t.Errorf("Int error = %v", err)
+	}
+}